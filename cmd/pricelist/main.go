@@ -0,0 +1,325 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/pricing"
+	yaml "gopkg.in/yaml.v2"
+
+	"git.arilot.com/kuberstack/kuberstack-installer/predefined"
+	"git.arilot.com/kuberstack/kuberstack-installer/steps/nodes"
+)
+
+const header = `# Generated by cmd/pricelist from the AWS Price List API, do not edit.
+# Run "go run ../cmd/pricelist/main.go -Output=prices.yml" in the predefined directory
+# with AWS credentials set to refresh the prices, it is not a part of "go generate".
+---
+
+`
+
+// Price List API is served from a couple of regions only
+const pricingRegion = "us-east-1"
+
+var (
+	output  = flag.String("Output", "prices.yml", "File to write the price table to")
+	regions = flag.String("Regions", "us-east-1,us-east-2,us-west-2,eu-west-1,eu-central-1", "Comma-separated list of regions to get the prices for")
+)
+
+func main() {
+	flag.Parse()
+
+	sess, err := session.NewSession(&aws.Config{Region: aws.String(pricingRegion)})
+	if err != nil {
+		panic(err)
+	}
+	svc := pricing.New(sess)
+
+	list := predefined.PriceList{
+		Currency:      "USD",
+		HoursPerMonth: 730,
+		Regions:       make(map[string]predefined.RegionPrices),
+	}
+
+	list.HostedZone, err = getHostedZonePrice(svc)
+	if err != nil {
+		panic(err)
+	}
+
+	for _, region := range strings.Split(*regions, ",") {
+		prices, err := getRegionPrices(svc, region)
+		if err != nil {
+			panic(err)
+		}
+
+		prices.SpotInstances, err = getSpotPrices(sess.Copy(&aws.Config{Region: aws.String(region)}))
+		if err != nil {
+			panic(err)
+		}
+
+		list.Regions[region] = prices
+	}
+
+	out, err := yaml.Marshal(&list)
+	if err != nil {
+		panic(err)
+	}
+
+	err = ioutil.WriteFile(*output, append([]byte(header), out...), 0644)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func getRegionPrices(svc *pricing.Pricing, region string) (predefined.RegionPrices, error) {
+	prices := predefined.RegionPrices{
+		Instances: make(map[string]float64),
+		Volumes:   make(map[string]predefined.VolumePrice),
+	}
+
+	handled := handledTypes()
+
+	err := getProducts(
+		svc,
+		"AmazonEC2",
+		map[string]string{
+			"regionCode":      region,
+			"productFamily":   "Compute Instance",
+			"operatingSystem": "Linux",
+			"tenancy":         "Shared",
+			"preInstalledSw":  "NA",
+			"capacitystatus":  "Used",
+			"licenseModel":    "No License required",
+		},
+		func(item aws.JSONValue, price float64) {
+			if t := attr(item, "instanceType"); handled[t] {
+				prices.Instances[t] = price
+			}
+		},
+	)
+	if err != nil {
+		return prices, err
+	}
+
+	err = getProducts(
+		svc,
+		"AmazonEC2",
+		map[string]string{"regionCode": region, "productFamily": "Storage"},
+		func(item aws.JSONValue, price float64) {
+			name := attr(item, "volumeApiName")
+			if name == "" {
+				return
+			}
+			volume := prices.Volumes[name]
+			volume.GBMonth = price
+			prices.Volumes[name] = volume
+		},
+	)
+	if err != nil {
+		return prices, err
+	}
+
+	err = getProducts(
+		svc,
+		"AmazonEC2",
+		map[string]string{"regionCode": region, "productFamily": "System Operation", "group": "EBS IOPS"},
+		func(item aws.JSONValue, price float64) {
+			name := attr(item, "volumeApiName")
+			if name == "" {
+				return
+			}
+			volume := prices.Volumes[name]
+			volume.IOPSMonth = price
+			prices.Volumes[name] = volume
+		},
+	)
+	if err != nil {
+		return prices, err
+	}
+
+	err = getProducts(
+		svc,
+		"AmazonEC2",
+		map[string]string{"regionCode": region, "productFamily": "Provisioned Throughput", "group": "EBS Throughput"},
+		func(item aws.JSONValue, price float64) {
+			name := attr(item, "volumeApiName")
+			if name == "" {
+				return
+			}
+			volume := prices.Volumes[name]
+			volume.ThroughputMonth = price
+			prices.Volumes[name] = volume
+		},
+	)
+	if err != nil {
+		return prices, err
+	}
+
+	err = getProducts(
+		svc,
+		"AmazonEC2",
+		map[string]string{"regionCode": region, "productFamily": "NAT Gateway"},
+		func(item aws.JSONValue, price float64) {
+			if strings.HasSuffix(attr(item, "usagetype"), "NatGateway-Hours") {
+				prices.NATGateway = price
+			}
+		},
+	)
+	if err != nil {
+		return prices, err
+	}
+
+	err = getProducts(
+		svc,
+		"AWSELB",
+		map[string]string{"regionCode": region, "productFamily": "Load Balancer"},
+		func(item aws.JSONValue, price float64) {
+			if strings.HasSuffix(attr(item, "usagetype"), "LoadBalancerUsage") {
+				prices.LoadBalancer = price
+			}
+		},
+	)
+
+	return prices, err
+}
+
+// getSpotPrices returns the current spot prices averaged over the region zones
+func getSpotPrices(sess *session.Session) (map[string]float64, error) {
+	handled := handledTypes()
+	sums := make(map[string]float64)
+	counts := make(map[string]int)
+
+	err := ec2.New(sess).DescribeSpotPriceHistoryPages(
+		&ec2.DescribeSpotPriceHistoryInput{
+			ProductDescriptions: aws.StringSlice([]string{"Linux/UNIX"}),
+			StartTime:           aws.Time(time.Now()),
+		},
+		func(page *ec2.DescribeSpotPriceHistoryOutput, lastPage bool) bool {
+			for _, item := range page.SpotPriceHistory {
+				t := aws.StringValue(item.InstanceType)
+				price, err := strconv.ParseFloat(aws.StringValue(item.SpotPrice), 64)
+				if err != nil || !handled[t] {
+					continue
+				}
+				sums[t] += price
+				counts[t]++
+			}
+			return true
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	prices := make(map[string]float64, len(sums))
+	for t, sum := range sums {
+		prices[t] = sum / float64(counts[t])
+	}
+
+	return prices, nil
+}
+
+func handledTypes() map[string]bool {
+	handled := make(map[string]bool)
+	for _, t := range nodes.GetNodeTypes() {
+		handled[t] = true
+	}
+	for _, t := range nodes.BastionTypes {
+		handled[t] = true
+	}
+	return handled
+}
+
+func getHostedZonePrice(svc *pricing.Pricing) (float64, error) {
+	var res float64
+
+	err := getProducts(
+		svc,
+		"AmazonRoute53",
+		map[string]string{"productFamily": "DNS Zone"},
+		func(item aws.JSONValue, price float64) {
+			res = price
+		},
+	)
+	if err == nil && res == 0 {
+		err = fmt.Errorf("No hosted zone price found")
+	}
+
+	return res, err
+}
+
+// getProducts calls the handler for every product matching the filters
+// with its first tier on-demand price, products with no such price are skipped
+func getProducts(
+	svc *pricing.Pricing,
+	service string,
+	filters map[string]string,
+	handler func(item aws.JSONValue, price float64),
+) error {
+	input := &pricing.GetProductsInput{
+		ServiceCode: aws.String(service),
+	}
+	for field, value := range filters {
+		input.Filters = append(
+			input.Filters,
+			&pricing.Filter{
+				Type:  aws.String(pricing.FilterTypeTermMatch),
+				Field: aws.String(field),
+				Value: aws.String(value),
+			},
+		)
+	}
+
+	return svc.GetProductsPages(
+		input,
+		func(page *pricing.GetProductsOutput, lastPage bool) bool {
+			for _, item := range page.PriceList {
+				if price, ok := onDemandPrice(item); ok {
+					handler(item, price)
+				}
+			}
+			return true
+		},
+	)
+}
+
+func attr(item aws.JSONValue, name string) string {
+	product, _ := item["product"].(map[string]interface{})
+	attrs, _ := product["attributes"].(map[string]interface{})
+	value, _ := attrs[name].(string)
+	return value
+}
+
+func onDemandPrice(item aws.JSONValue) (float64, bool) {
+	terms, _ := item["terms"].(map[string]interface{})
+	onDemand, _ := terms["OnDemand"].(map[string]interface{})
+
+	for _, term := range onDemand {
+		termMap, _ := term.(map[string]interface{})
+		dimensions, _ := termMap["priceDimensions"].(map[string]interface{})
+
+		for _, dimension := range dimensions {
+			dimensionMap, _ := dimension.(map[string]interface{})
+			if begin, _ := dimensionMap["beginRange"].(string); begin != "" && begin != "0" {
+				continue
+			}
+
+			perUnit, _ := dimensionMap["pricePerUnit"].(map[string]interface{})
+			usd, _ := perUnit["USD"].(string)
+
+			price, err := strconv.ParseFloat(usd, 64)
+			if err == nil && price > 0 {
+				return price, true
+			}
+		}
+	}
+
+	return 0, false
+}
@@ -1,6 +1,7 @@
 package db
 
 import (
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
 	"sync"
@@ -13,6 +14,8 @@ import (
 
 var (
 	savedstatesBucket = []byte("savedstates")
+	// logsBucket keeps a nested bucket of the log entries per session keyed by Seq
+	logsBucket = []byte("logs")
 )
 
 type boltDB struct {
@@ -227,6 +230,14 @@ func deleteExpired(conn *bolt.DB, toRemove [][]byte) (err error) {
 	for _, key := range toRemove {
 		err = conn.Update(
 			func(tx *bolt.Tx) error {
+				logs := tx.Bucket(logsBucket)
+				if logs != nil && logs.Bucket(key) != nil {
+					err := logs.DeleteBucket(key)
+					if err != nil {
+						return err
+					}
+				}
+
 				bucket := tx.Bucket(savedstatesBucket)
 				if bucket == nil {
 					return nil
@@ -241,3 +252,86 @@ func deleteExpired(conn *bolt.DB, toRemove [][]byte) (err error) {
 
 	return nil
 }
+
+func (conn *boltDB) AppendLogs(id string, entries []savedstate.LogEntry) error {
+	conn.RLock()
+	defer conn.RUnlock()
+
+	return conn.db.Update(
+		func(tx *bolt.Tx) error {
+			logs, err := tx.CreateBucketIfNotExists(logsBucket)
+			if err != nil {
+				return err
+			}
+
+			bucket, err := logs.CreateBucketIfNotExists([]byte(id))
+			if err != nil {
+				return err
+			}
+
+			for i := range entries {
+				entries[i].Seq, err = bucket.NextSequence()
+				if err != nil {
+					return err
+				}
+
+				value, err := json.Marshal(entries[i])
+				if err != nil {
+					panic(err)
+				}
+
+				err = bucket.Put(seqKey(entries[i].Seq), value)
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	)
+}
+
+func (conn *boltDB) GetLogs(id string, after uint64, limit int, job string) ([]savedstate.LogEntry, error) {
+	conn.RLock()
+	defer conn.RUnlock()
+
+	tx, err := conn.db.Begin(false)
+	if err != nil {
+		return nil, err
+	}
+	defer closeRO(tx)
+
+	entries := make([]savedstate.LogEntry, 0, limit)
+
+	logs := tx.Bucket(logsBucket)
+	if logs == nil {
+		return entries, nil
+	}
+
+	bucket := logs.Bucket([]byte(id))
+	if bucket == nil {
+		return entries, nil
+	}
+
+	cursor := bucket.Cursor()
+	for key, value := cursor.Seek(seqKey(after + 1)); key != nil && len(entries) < limit; key, value = cursor.Next() {
+		var entry savedstate.LogEntry
+		err = json.Unmarshal(value, &entry)
+		if err != nil {
+			return nil, err
+		}
+
+		if job == "" || entry.Job == job {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, nil
+}
+
+// seqKey returns the big endian key, so the keys are sorted by Seq
+func seqKey(seq uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, seq)
+	return key
+}
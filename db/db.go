@@ -21,6 +21,12 @@ type Connect interface {
 	InsertState(string) error
 	GetState(string) (*savedstate.State, error)
 	Cleanup() ([][]byte, error)
+
+	// AppendLogs adds the entries to the session log history at once setting the entries Seq
+	AppendLogs(string, []savedstate.LogEntry) error
+	// GetLogs returns up to limit entries of the session following the after Seq,
+	// only the entries of the job are returned if it is set
+	GetLogs(id string, after uint64, limit int, job string) ([]savedstate.LogEntry, error)
 }
 
 // Open creates a new DB connection
@@ -2,7 +2,6 @@ package kops
 
 import (
 	"fmt"
-	"os"
 	"time"
 
 	"git.arilot.com/kuberstack/kopsEmbeded"
@@ -57,6 +56,54 @@ func CheckKopsRun(
 			return 5, true
 		}
 		return 0, true
+	case kopsConfig.KopsCreateGroup:
+		logger.Debug("Kops called", "cmd", cmdItself, "params", kopsConfig)
+		err := ExecuteCreateGroup(kopsConfig, logger)
+		if err != nil {
+			logger.PrintErr("Kops create instance group", "err", err)
+			return 6, true
+		}
+		return 0, true
+	case kopsConfig.KopsReplaceGroup:
+		logger.Debug("Kops called", "cmd", cmdItself, "params", kopsConfig)
+		err := ExecuteReplaceGroup(kopsConfig, logger)
+		if err != nil {
+			logger.PrintErr("Kops replace instance group", "err", err)
+			return 7, true
+		}
+		return 0, true
+	case kopsConfig.KopsDeleteGroup:
+		logger.Debug("Kops called", "cmd", cmdItself, "params", kopsConfig)
+		err := ExecuteDeleteGroup(kopsConfig, logger)
+		if err != nil {
+			logger.PrintErr("Kops delete instance group", "err", err)
+			return 8, true
+		}
+		return 0, true
+	case kopsConfig.KopsGetCluster:
+		logger.Debug("Kops called", "cmd", cmdItself, "params", kopsConfig)
+		err := ExecuteGetCluster(kopsConfig, logger)
+		if err != nil {
+			logger.PrintErr("Kops get cluster", "err", err)
+			return 10, true
+		}
+		return 0, true
+	case kopsConfig.KopsReplaceCluster:
+		logger.Debug("Kops called", "cmd", cmdItself, "params", kopsConfig)
+		err := ExecuteReplaceCluster(kopsConfig, logger)
+		if err != nil {
+			logger.PrintErr("Kops replace cluster", "err", err)
+			return 11, true
+		}
+		return 0, true
+	case kopsConfig.KopsGetGroup:
+		logger.Debug("Kops called", "cmd", cmdItself, "params", kopsConfig)
+		err := ExecuteGetGroup(kopsConfig, logger)
+		if err != nil {
+			logger.PrintErr("Kops get instance group", "err", err)
+			return 12, true
+		}
+		return 0, true
 		// case kubectlConfig.KubectlGetNodes:
 		// 	logger.Debug("Kubectl called", "cmd", cmdItself)
 		// 	err := kubectl.GetNodes(kubectlConfig, logger)
@@ -79,6 +126,15 @@ type Config struct {
 	TmpDir       string        `long:"tmpDir" description:"directory to save the SSH pub keys to be passed to kops" default:"./"`
 	Timeout      time.Duration `long:"timeout" description:"Max time kops command allowed to execute" default:"120s"`
 
+	KopsCreateGroup  bool `long:"kopsCreateGroup" description:"run embedded kops binary to create an instance group from the manifest file"`
+	KopsReplaceGroup bool `long:"kopsReplaceGroup" description:"run embedded kops binary to replace an instance group with the manifest file"`
+	KopsDeleteGroup  bool `long:"kopsDeleteGroup" description:"run embedded kops binary to delete an instance group"`
+	Force            bool `long:"force" description:"create the resources missing on kops replace"`
+
+	KopsGetCluster     bool `long:"kopsGetCluster" description:"run embedded kops binary to print the cluster manifest to stdout"`
+	KopsReplaceCluster bool `long:"kopsReplaceCluster" description:"run embedded kops binary to replace the cluster spec with the manifest file"`
+	KopsGetGroup       bool `long:"kopsGetGroup" description:"run embedded kops binary to print the instance group manifest to stdout"`
+
 	Zones              string `long:"zones" description:"Zones in which to run the cluster"`
 	Name               string `long:"name" description:"Name of cluster"`
 	State              string `long:"state" description:"Location of state storage"`
@@ -91,37 +147,50 @@ type Config struct {
 	NodeSize           string `long:"node-size" description:"Set instance size for nodes"`
 	NodeVolumeSize     int32  `long:"node-volume-size" description:"Set instance volume size (in GB) for nodes	"`
 	SSHPublicKey       string `long:"ssh-public-key" description:"SSH public key to use"`
+	Filename           string `long:"filename" description:"Manifest file to create or replace the resources with"`
+	GroupName          string `long:"group-name" description:"Name of the instance group"`
+	CloudLabels        string `long:"cloud-labels" description:"A list of KV pairs used to tag all the AWS resources (eg \"Owner=John Doe,Team=Some Team\")"`
+	Image              string `long:"image" description:"Image to use for all instances, kops picks the default one if empty"`
+	VPC                string `long:"vpc" description:"Existing VPC ID to deploy the cluster into, kops creates a new one if empty"`
+	NetworkCIDR        string `long:"network-cidr" description:"CIDR of the existing VPC"`
+	Subnets            string `long:"subnets" description:"Comma separated list of the existing subnet IDs, one per zone"`
+	Topology           string `long:"topology" description:"Network topology, public or private" default:"public"`
+	Networking         string `long:"networking" description:"Networking mode to use" default:"kubenet"`
+	SSHAccess          string `long:"ssh-access" description:"Comma separated list of the CIDRs SSH access is allowed from"`
+	Bastion            bool   `long:"bastion" description:"Create a bastion host, the private topology only"`
+	KubernetesVersion  string `long:"kubernetes-version" description:"Kubernetes version to be installed, the channel recommended one if empty"`
+	Channel            string `long:"channel" description:"kops channel to take the images and addons versions from" default:"stable"`
+	AdminAccess        string `long:"admin-access" description:"Comma separated list of the CIDRs the Kubernetes API access is allowed from"`
+	Authorization      string `long:"authorization" description:"Kubernetes API authorization mode, RBAC or AlwaysAllow" default:"RBAC"`
+	APILoadBalancer    string `long:"api-loadbalancer-type" description:"Kubernetes API load balancer type, public or internal" default:"public"`
+	Target             string `long:"target" description:"Target to apply the changes to: direct, terraform or cloudformation" default:"direct"`
+	Out                string `long:"out" description:"Directory to write the terraform or cloudformation code to"`
 }
 
 // ExecuteCreate calls an embeded kops create cluster with the params provided
 func ExecuteCreate(kopsConfig Config, logger *structlog.Logger) error {
-	time.AfterFunc(
-		kopsConfig.Timeout,
-		func() {
-			_, err := fmt.Fprintf(os.Stderr, "Timeout (%v) exceeded\n", kopsConfig.Timeout)
-			if err != nil {
-				panic(err)
-			}
-			os.Exit(9)
-		},
-	)
+	// kops opens the access to the world if the CIDRs are not set
+	if kopsConfig.SSHAccess == "" || kopsConfig.AdminAccess == "" {
+		return fmt.Errorf("SSH and Kubernetes API access CIDRs are required")
+	}
 
 	params := []string{
 		"create",
 		"cluster",
-		"--admin-access=0.0.0.0/0",
-		"--api-loadbalancer-type=public",
-		"--associate-public-ip=true",
-		"--authorization=AlwaysAllow",
-		"--channel=stable",
 		"--cloud=aws",
 		"--dns=public",
 		"--model=config,proto,cloudup",
-		"--ssh-access=0.0.0.0/0",
-		"--target=direct",
-		"--topology=public",
 		"--yes",
-		"--networking=kubenet",
+		fmt.Sprintf("--target=%v", kopsConfig.Target),
+		fmt.Sprintf("--topology=%v", kopsConfig.Topology),
+		// the private topology nodes are reachable through the bastion only
+		fmt.Sprintf("--associate-public-ip=%v", kopsConfig.Topology == "public"),
+		fmt.Sprintf("--networking=%v", kopsConfig.Networking),
+		fmt.Sprintf("--channel=%v", kopsConfig.Channel),
+		fmt.Sprintf("--ssh-access=%v", kopsConfig.SSHAccess),
+		fmt.Sprintf("--admin-access=%v", kopsConfig.AdminAccess),
+		fmt.Sprintf("--authorization=%v", kopsConfig.Authorization),
+		fmt.Sprintf("--api-loadbalancer-type=%v", kopsConfig.APILoadBalancer),
 		fmt.Sprintf("--zones=%v", kopsConfig.Zones),
 		fmt.Sprintf("--name=%v", kopsConfig.Name),
 		fmt.Sprintf("--state=%v", kopsConfig.State),
@@ -134,9 +203,33 @@ func ExecuteCreate(kopsConfig Config, logger *structlog.Logger) error {
 		fmt.Sprintf("--node-size=%v", kopsConfig.NodeSize),
 		fmt.Sprintf("--node-volume-size=%v", kopsConfig.NodeVolumeSize),
 		fmt.Sprintf("--ssh-public-key=%v", kopsConfig.SSHPublicKey),
+		fmt.Sprintf("--cloud-labels=%v", kopsConfig.CloudLabels),
+		fmt.Sprintf("--image=%v", kopsConfig.Image),
 		"--logtostderr",
 	}
 
+	if kopsConfig.Bastion {
+		params = append(params, "--bastion")
+	}
+
+	if kopsConfig.Out != "" {
+		params = append(params, fmt.Sprintf("--out=%v", kopsConfig.Out))
+	}
+
+	if kopsConfig.KubernetesVersion != "" {
+		params = append(params, fmt.Sprintf("--kubernetes-version=%v", kopsConfig.KubernetesVersion))
+	}
+
+	// The subnets are shared with the cluster, kops names them after the zones
+	if kopsConfig.VPC != "" {
+		params = append(
+			params,
+			fmt.Sprintf("--vpc=%v", kopsConfig.VPC),
+			fmt.Sprintf("--network-cidr=%v", kopsConfig.NetworkCIDR),
+			fmt.Sprintf("--subnets=%v", kopsConfig.Subnets),
+		)
+	}
+
 	logger.Debug("Calling embeded kops", "params", params)
 
 	return kopsEmbeded.Execute(params...)
@@ -144,23 +237,17 @@ func ExecuteCreate(kopsConfig Config, logger *structlog.Logger) error {
 
 // ExecuteUpdate calls an embeded kops update cluster
 func ExecuteUpdate(kopsConfig Config, logger *structlog.Logger) error {
-	time.AfterFunc(
-		kopsConfig.Timeout,
-		func() {
-			_, err := fmt.Fprintf(os.Stderr, "Timeout (%v) exceeded\n", kopsConfig.Timeout)
-			if err != nil {
-				panic(err)
-			}
-			os.Exit(9)
-		},
-	)
-
 	params := []string{
 		"update",
 		"cluster",
 		"--yes",
 		fmt.Sprintf("--name=%v", kopsConfig.Name),
 		fmt.Sprintf("--state=%v", kopsConfig.State),
+		fmt.Sprintf("--target=%v", kopsConfig.Target),
+	}
+
+	if kopsConfig.Out != "" {
+		params = append(params, fmt.Sprintf("--out=%v", kopsConfig.Out))
 	}
 
 	logger.Debug("Calling embeded kops", "params", params)
@@ -170,17 +257,6 @@ func ExecuteUpdate(kopsConfig Config, logger *structlog.Logger) error {
 
 // ExecuteRolling calls an embeded kops rolling update cluster
 func ExecuteRolling(kopsConfig Config, logger *structlog.Logger) error {
-	time.AfterFunc(
-		kopsConfig.Timeout,
-		func() {
-			_, err := fmt.Fprintf(os.Stderr, "Timeout (%v) exceeded\n", kopsConfig.Timeout)
-			if err != nil {
-				panic(err)
-			}
-			os.Exit(9)
-		},
-	)
-
 	params := []string{
 		"rolling-update",
 		"cluster",
@@ -199,17 +275,6 @@ func ExecuteRolling(kopsConfig Config, logger *structlog.Logger) error {
 
 // ExecuteValidate calls an embeded kops validate cluster with the params provided
 func ExecuteValidate(kopsConfig Config, logger *structlog.Logger) error {
-	time.AfterFunc(
-		kopsConfig.Timeout,
-		func() {
-			_, err := fmt.Fprintf(os.Stderr, "Timeout (%v) exceeded\n", kopsConfig.Timeout)
-			if err != nil {
-				panic(err)
-			}
-			os.Exit(9)
-		},
-	)
-
 	params := []string{
 		"validate",
 		"cluster",
@@ -224,17 +289,6 @@ func ExecuteValidate(kopsConfig Config, logger *structlog.Logger) error {
 
 // ExecuteDelete calls an embeded kops delete cluster with the params provided
 func ExecuteDelete(kopsConfig Config, logger *structlog.Logger) error {
-	time.AfterFunc(
-		kopsConfig.Timeout,
-		func() {
-			_, err := fmt.Fprintf(os.Stderr, "Timeout (%v) exceeded\n", kopsConfig.Timeout)
-			if err != nil {
-				panic(err)
-			}
-			os.Exit(9)
-		},
-	)
-
 	params := []string{
 		"delete",
 		"cluster",
@@ -247,3 +301,92 @@ func ExecuteDelete(kopsConfig Config, logger *structlog.Logger) error {
 
 	return kopsEmbeded.Execute(params...)
 }
+
+// ExecuteCreateGroup calls an embeded kops create with the instance group manifest provided
+func ExecuteCreateGroup(kopsConfig Config, logger *structlog.Logger) error {
+	params := []string{
+		"create",
+		fmt.Sprintf("--filename=%v", kopsConfig.Filename),
+		fmt.Sprintf("--state=%v", kopsConfig.State),
+	}
+
+	logger.Debug("Calling embeded kops", "params", params)
+
+	return kopsEmbeded.Execute(params...)
+}
+
+// ExecuteReplaceGroup calls an embeded kops replace with the instance group manifest provided
+func ExecuteReplaceGroup(kopsConfig Config, logger *structlog.Logger) error {
+	params := []string{
+		"replace",
+		fmt.Sprintf("--filename=%v", kopsConfig.Filename),
+		fmt.Sprintf("--state=%v", kopsConfig.State),
+	}
+	if kopsConfig.Force {
+		params = append(params, "--force")
+	}
+
+	logger.Debug("Calling embeded kops", "params", params)
+
+	return kopsEmbeded.Execute(params...)
+}
+
+// ExecuteDeleteGroup calls an embeded kops delete instancegroup with the params provided
+func ExecuteDeleteGroup(kopsConfig Config, logger *structlog.Logger) error {
+	params := []string{
+		"delete",
+		"instancegroup",
+		kopsConfig.GroupName,
+		fmt.Sprintf("--name=%v", kopsConfig.Name),
+		fmt.Sprintf("--state=%v", kopsConfig.State),
+		"--yes",
+	}
+
+	logger.Debug("Calling embeded kops", "params", params)
+
+	return kopsEmbeded.Execute(params...)
+}
+
+// ExecuteGetCluster calls an embeded kops get cluster printing the cluster manifest as YAML
+func ExecuteGetCluster(kopsConfig Config, logger *structlog.Logger) error {
+	params := []string{
+		"get",
+		"cluster",
+		kopsConfig.Name,
+		fmt.Sprintf("--state=%v", kopsConfig.State),
+		"--output=yaml",
+	}
+
+	logger.Debug("Calling embeded kops", "params", params)
+
+	return kopsEmbeded.Execute(params...)
+}
+
+// ExecuteGetGroup calls an embeded kops get instancegroups printing the group manifest
+func ExecuteGetGroup(kopsConfig Config, logger *structlog.Logger) error {
+	params := []string{
+		"get",
+		"instancegroups",
+		kopsConfig.GroupName,
+		fmt.Sprintf("--name=%v", kopsConfig.Name),
+		fmt.Sprintf("--state=%v", kopsConfig.State),
+		"--output=yaml",
+	}
+
+	logger.Debug("Calling embeded kops", "params", params)
+
+	return kopsEmbeded.Execute(params...)
+}
+
+// ExecuteReplaceCluster calls an embeded kops replace with the cluster manifest provided
+func ExecuteReplaceCluster(kopsConfig Config, logger *structlog.Logger) error {
+	params := []string{
+		"replace",
+		fmt.Sprintf("--filename=%v", kopsConfig.Filename),
+		fmt.Sprintf("--state=%v", kopsConfig.State),
+	}
+
+	logger.Debug("Calling embeded kops", "params", params)
+
+	return kopsEmbeded.Execute(params...)
+}
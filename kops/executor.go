@@ -0,0 +1,198 @@
+package kops
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"reflect"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Executor runs the embedded kops command the config is set for.
+// The command is killed once the context is canceled or its deadline is exceeded.
+type Executor interface {
+	Run(ctx context.Context, config Config, env Env) (*Result, error)
+}
+
+// Env is the environment kops runs in
+type Env struct {
+	// HomeDir keeps the kubecfg and the manifests of the session
+	HomeDir   string
+	AccessKey string
+	SecretKey string
+
+	// Output is called with every line of the stream as soon as it is written if set,
+	// it is called for stdout and stderr concurrently
+	Output func(stream string, line string)
+}
+
+// Output streams
+const (
+	StreamStdout = "stdout"
+	StreamStderr = "stderr"
+)
+
+// Result is the output of the kops command, stdout keeps the manifests printed and stderr keeps the log
+type Result struct {
+	Stdout   []byte
+	Stderr   []byte
+	Duration time.Duration
+}
+
+// RunError is the kops command failure
+type RunError struct {
+	Command string
+	// ExitCode is -1 if the command has not exited by itself
+	ExitCode int
+	Stderr   []byte
+	Err      error
+}
+
+func (e *RunError) Error() string {
+	switch {
+	case e.Timeout():
+		return fmt.Sprintf("Kops %s timeout exceeded", e.Command)
+	case e.Canceled():
+		return fmt.Sprintf("Kops %s canceled", e.Command)
+	case e.ExitCode >= 0:
+		return fmt.Sprintf("Kops %s failed with exit code %d", e.Command, e.ExitCode)
+	}
+	return fmt.Sprintf("Kops %s failed: %v", e.Command, e.Err)
+}
+
+// Timeout checks if the command is killed on the context deadline
+func (e *RunError) Timeout() bool {
+	return e.Err == context.DeadlineExceeded
+}
+
+// Canceled checks if the command is killed on the context cancel
+func (e *RunError) Canceled() bool {
+	return e.Err == context.Canceled
+}
+
+// SelfExecutor runs kops by calling the app itself with the kops flags
+type SelfExecutor struct {
+	Itself string
+}
+
+// NewSelfExecutor returns the executor calling the app binary provided
+func NewSelfExecutor(itself string) *SelfExecutor {
+	return &SelfExecutor{Itself: itself}
+}
+
+// Run calls the app itself with the config flags and the env provided
+func (e *SelfExecutor) Run(ctx context.Context, config Config, env Env) (*Result, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, e.Itself, config.Args()...) // #nosec
+	cmd.Env = []string{
+		fmt.Sprintf("HOME=%v", env.HomeDir),
+		fmt.Sprintf("AWS_ACCESS_KEY=%v", env.AccessKey),
+		fmt.Sprintf("AWS_SECRET_KEY=%v", env.SecretKey),
+	}
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if env.Output != nil {
+		stdoutLines := &lineWriter{stream: StreamStdout, output: env.Output}
+		stderrLines := &lineWriter{stream: StreamStderr, output: env.Output}
+		defer stdoutLines.flush()
+		defer stderrLines.flush()
+
+		cmd.Stdout = io.MultiWriter(&stdout, stdoutLines)
+		cmd.Stderr = io.MultiWriter(&stderr, stderrLines)
+	}
+
+	start := time.Now()
+	err := cmd.Run()
+
+	res := &Result{
+		Stdout:   stdout.Bytes(),
+		Stderr:   stderr.Bytes(),
+		Duration: time.Since(start),
+	}
+	if err != nil {
+		runErr := &RunError{
+			Command:  config.Command(),
+			ExitCode: -1,
+			Stderr:   res.Stderr,
+			Err:      err,
+		}
+		if ctx.Err() != nil {
+			runErr.Err = ctx.Err()
+		} else if exitErr, ok := err.(*exec.ExitError); ok {
+			if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Exited() {
+				runErr.ExitCode = status.ExitStatus()
+			}
+		}
+		return res, runErr
+	}
+
+	return res, nil
+}
+
+// Args returns the command-line flags of the config fields set,
+// the flags of the zero values are skipped, so the defaults are taken for them
+func (c Config) Args() []string {
+	var args []string
+
+	value := reflect.ValueOf(c)
+	for i := 0; i < value.NumField(); i++ {
+		name := value.Type().Field(i).Tag.Get("long")
+		field := value.Field(i)
+
+		switch {
+		case name == "":
+		case field.Kind() == reflect.Bool:
+			if field.Bool() {
+				args = append(args, "--"+name)
+			}
+		case field.Interface() != reflect.Zero(field.Type()).Interface():
+			args = append(args, fmt.Sprintf("--%s=%v", name, field.Interface()))
+		}
+	}
+
+	return args
+}
+
+// Command returns the name of the kops command the config is set for
+func (c Config) Command() string {
+	for _, arg := range c.Args() {
+		if strings.HasPrefix(arg, "--kops") {
+			return strings.TrimPrefix(arg, "--kops")
+		}
+	}
+	return ""
+}
+
+// lineWriter passes the output to the callback line by line
+type lineWriter struct {
+	stream string
+	output func(stream string, line string)
+	buf    []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.output(w.stream, string(bytes.TrimSuffix(w.buf[:i], []byte("\r"))))
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// flush passes the last line not terminated with a newline
+func (w *lineWriter) flush() {
+	if len(w.buf) > 0 {
+		w.output(w.stream, string(w.buf))
+		w.buf = nil
+	}
+}
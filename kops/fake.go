@@ -0,0 +1,74 @@
+package kops
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// FakeExecutor records the calls instead of running kops,
+// so the flows calling kops run with no AWS account
+type FakeExecutor struct {
+	// Results are returned by the command name, like "Create" or "GetGroup",
+	// the empty result is returned for the commands not listed
+	Results map[string]*Result
+	// Errors are returned by the command name along with the result
+	Errors map[string]error
+
+	mu    sync.Mutex
+	calls []FakeCall
+}
+
+// FakeCall is a kops call recorded
+type FakeCall struct {
+	Config Config
+	Env    Env
+}
+
+// NewFakeExecutor returns the fake executor with no results set
+func NewFakeExecutor() *FakeExecutor {
+	return &FakeExecutor{
+		Results: make(map[string]*Result),
+		Errors:  make(map[string]error),
+	}
+}
+
+// Run records the call and returns the result and the error set for the command
+func (e *FakeExecutor) Run(ctx context.Context, config Config, env Env) (*Result, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.calls = append(e.calls, FakeCall{Config: config, Env: env})
+
+	if ctx.Err() != nil {
+		return &Result{}, &RunError{Command: config.Command(), ExitCode: -1, Err: ctx.Err()}
+	}
+
+	res := e.Results[config.Command()]
+	if res == nil {
+		res = &Result{}
+	}
+
+	if env.Output != nil {
+		for _, line := range strings.SplitAfter(string(res.Stdout), "\n") {
+			if line != "" {
+				env.Output(StreamStdout, strings.TrimSuffix(line, "\n"))
+			}
+		}
+		for _, line := range strings.SplitAfter(string(res.Stderr), "\n") {
+			if line != "" {
+				env.Output(StreamStderr, strings.TrimSuffix(line, "\n"))
+			}
+		}
+	}
+
+	return res, e.Errors[config.Command()]
+}
+
+// Calls returns the calls recorded in the order they are made
+func (e *FakeExecutor) Calls() []FakeCall {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return append([]FakeCall(nil), e.calls...)
+}
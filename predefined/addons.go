@@ -0,0 +1,12 @@
+package predefined
+
+import (
+	"text/template"
+
+	"git.arilot.com/kuberstack/kuberstack-installer/predefined/gen"
+)
+
+// ClusterAutoscaler is a template of the cluster autoscaler addon manifest
+var ClusterAutoscaler = template.Must(
+	template.New("cluster-autoscaler").Parse(string(gen.MustAsset("addons/cluster-autoscaler.yaml"))),
+)
@@ -5,18 +5,54 @@ import (
 	yaml "gopkg.in/yaml.v2"
 )
 
-// Types is a variable holding the cluster types defined
-var Types []struct {
+// GroupPreset holds the defaults and the limits for a group of nodes
+type GroupPreset struct {
+	Count        int64  `yaml:"count"`
+	Min          int64  `yaml:"min"`
+	Max          int64  `yaml:"max"`
+	InstanceType string `yaml:"instanceType"`
+	StorageSize  int64  `yaml:"storageSize"`
+	StorageType  string `yaml:"storageType"`
+
+	// MaxSize is the default autoscaling upper bound, the group is fixed size if it is not above Count
+	MaxSize int64 `yaml:"maxSize"`
+}
+
+// ClusterType is a cluster preset
+type ClusterType struct {
 	ID          int     `yaml:"id"`
 	Name        string  `yaml:"name"`
 	ShortName   string  `yaml:"shortName"`
 	Description string  `yaml:"description"`
 	Price       float64 `yaml:"price"`
+
+	Masters GroupPreset `yaml:"masters"`
+	Nodes   GroupPreset `yaml:"nodes"`
+
+	// InstanceFamilies allowed for the cluster, any family is allowed if empty
+	InstanceFamilies []string `yaml:"instanceFamilies"`
+	// MultiZone requires the nodes to be spread over several zones
+	MultiZone bool `yaml:"multiZone"`
+	// Software is a list of product IDs bundled with the type
+	Software []int `yaml:"software"`
 }
 
+// Types is a variable holding the cluster types defined
+var Types []ClusterType
+
 func init() {
 	err := yaml.Unmarshal(gen.MustAsset("clustertypes.yml"), &Types)
 	if err != nil {
 		panic(err)
 	}
 }
+
+// GetType returns a cluster type by ID or nil if there is no such type
+func GetType(id int64) *ClusterType {
+	for i := range Types {
+		if int64(Types[i].ID) == id {
+			return &Types[i]
+		}
+	}
+	return nil
+}
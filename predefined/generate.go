@@ -1,3 +1,3 @@
 package predefined
 
-//go:generate go-bindata -pkg gen -o gen/predefined.go clustertypes.yml products.yml
+//go:generate go-bindata -pkg gen -o gen/predefined.go clustertypes.yml images.yml instancetypes.yml prices.yml products.yml versions.yml addons/...
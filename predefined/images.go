@@ -0,0 +1,47 @@
+package predefined
+
+import (
+	"git.arilot.com/kuberstack/kuberstack-installer/predefined/gen"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// MachineImage is a curated machine image,
+// the image is looked up by the owner and the name pattern in every region
+type MachineImage struct {
+	ID          string `yaml:"id"`
+	Name        string `yaml:"name"`
+	Owner       string `yaml:"owner"`
+	NamePattern string `yaml:"namePattern"`
+	// Hardened image owner is configured in runtime
+	Hardened bool `yaml:"hardened"`
+}
+
+// Images is a variable holding the curated machine images
+var Images []MachineImage
+
+func init() {
+	err := yaml.Unmarshal(gen.MustAsset("images.yml"), &Images)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// GetImage returns the curated image of the ID provided or nil if there is no such image
+func GetImage(id string) *MachineImage {
+	for i := range Images {
+		if Images[i].ID == id {
+			return &Images[i]
+		}
+	}
+	return nil
+}
+
+// GetHardenedImage returns the hardened base image or nil if there is no such image
+func GetHardenedImage() *MachineImage {
+	for i := range Images {
+		if Images[i].Hardened {
+			return &Images[i]
+		}
+	}
+	return nil
+}
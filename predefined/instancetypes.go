@@ -0,0 +1,39 @@
+package predefined
+
+import (
+	"git.arilot.com/kuberstack/kuberstack-installer/predefined/gen"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// InstanceTypeSpec is a hardware spec of an EC2 instance type,
+// memory is in GiB
+type InstanceTypeSpec struct {
+	Name         string  `yaml:"name"`
+	VCPU         int64   `yaml:"vcpu"`
+	Memory       float64 `yaml:"memory"`
+	Architecture string  `yaml:"architecture"`
+	Network      string  `yaml:"network"`
+	EBSOptimized bool    `yaml:"ebsOptimized"`
+	GPUs         int64   `yaml:"gpus"`
+	Generation   string  `yaml:"generation"`
+}
+
+// InstanceTypes is a variable holding the instance types specs embedded
+var InstanceTypes []InstanceTypeSpec
+
+func init() {
+	err := yaml.Unmarshal(gen.MustAsset("instancetypes.yml"), &InstanceTypes)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// GetInstanceType returns the spec of the type named or nil if the type is unknown
+func GetInstanceType(name string) *InstanceTypeSpec {
+	for i := range InstanceTypes {
+		if InstanceTypes[i].Name == name {
+			return &InstanceTypes[i]
+		}
+	}
+	return nil
+}
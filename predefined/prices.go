@@ -0,0 +1,52 @@
+package predefined
+
+import (
+	"git.arilot.com/kuberstack/kuberstack-installer/predefined/gen"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// VolumePrice is a monthly price of an EBS volume type,
+// throughput is priced per MiB/s
+type VolumePrice struct {
+	GBMonth         float64 `yaml:"gbMonth"`
+	IOPSMonth       float64 `yaml:"iopsMonth,omitempty"`
+	ThroughputMonth float64 `yaml:"throughputMonth,omitempty"`
+}
+
+// RegionPrices holds the on-demand prices for a region,
+// instances, load balancers and NAT gateways are priced per hour
+type RegionPrices struct {
+	Instances map[string]float64 `yaml:"instances"`
+	// SpotInstances are the average spot prices over the region zones
+	SpotInstances map[string]float64     `yaml:"spotInstances"`
+	Volumes       map[string]VolumePrice `yaml:"volumes"`
+	LoadBalancer  float64                `yaml:"loadBalancer"`
+	NATGateway    float64                `yaml:"natGateway"`
+}
+
+// PriceList is a price table for all the regions known
+type PriceList struct {
+	Currency      string                  `yaml:"currency"`
+	HoursPerMonth float64                 `yaml:"hoursPerMonth"`
+	HostedZone    float64                 `yaml:"hostedZone"`
+	Regions       map[string]RegionPrices `yaml:"regions"`
+}
+
+// Prices is a variable holding the price table embedded
+var Prices PriceList
+
+func init() {
+	err := yaml.Unmarshal(gen.MustAsset("prices.yml"), &Prices)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// GetRegionPrices returns the prices for a region or nil if the region is not priced
+func GetRegionPrices(region string) *RegionPrices {
+	prices, ok := Prices.Regions[region]
+	if !ok {
+		return nil
+	}
+	return &prices
+}
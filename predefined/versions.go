@@ -0,0 +1,86 @@
+package predefined
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"git.arilot.com/kuberstack/kuberstack-installer/predefined/gen"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// KubernetesVersion is a Kubernetes version the embedded kops supports
+type KubernetesVersion struct {
+	Version string `yaml:"version"`
+	// Autoscaler is the cluster autoscaler image version for the Kubernetes one
+	Autoscaler string `yaml:"autoscaler"`
+	// Default version is used if none selected
+	Default bool `yaml:"default"`
+}
+
+// VersionsTable is the Kubernetes versions and the kops channels supported
+type VersionsTable struct {
+	// Kops is the kops release (major.minor) the embedded kops is built from
+	Kops           string              `yaml:"kops"`
+	DefaultChannel string              `yaml:"defaultChannel"`
+	Channels       []string            `yaml:"channels"`
+	Versions       []KubernetesVersion `yaml:"versions"`
+}
+
+// Versions is a variable holding the versions table
+var Versions VersionsTable
+
+func init() {
+	err := yaml.Unmarshal(gen.MustAsset("versions.yml"), &Versions)
+	if err != nil {
+		panic(err)
+	}
+
+	for _, version := range Versions.Versions {
+		if !versionNotNewer(version.Version, Versions.Kops) {
+			panic(fmt.Errorf("Kubernetes version %q is newer than kops %q", version.Version, Versions.Kops))
+		}
+	}
+}
+
+// versionNotNewer checks the major.minor of the version is not newer than the release one
+func versionNotNewer(version string, release string) bool {
+	v, r := strings.Split(version, "."), strings.Split(release, ".")
+	for i := 0; i < 2; i++ {
+		if i >= len(v) || i >= len(r) {
+			return false
+		}
+		vn, err := strconv.Atoi(v[i])
+		if err != nil {
+			return false
+		}
+		rn, err := strconv.Atoi(r[i])
+		if err != nil {
+			return false
+		}
+		if vn != rn {
+			return vn < rn
+		}
+	}
+	return true
+}
+
+// GetKubernetesVersion returns the supported Kubernetes version or nil if the version is not supported
+func GetKubernetesVersion(version string) *KubernetesVersion {
+	for i := range Versions.Versions {
+		if Versions.Versions[i].Version == version {
+			return &Versions.Versions[i]
+		}
+	}
+	return nil
+}
+
+// DefaultKubernetesVersion returns the version used if none selected
+func DefaultKubernetesVersion() string {
+	for _, version := range Versions.Versions {
+		if version.Default {
+			return version.Version
+		}
+	}
+	return ""
+}
@@ -1,8 +1,11 @@
 package protocol
 
 import (
+	"context"
+	"fmt"
 	"net/http"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/go-openapi/runtime/middleware"
@@ -19,12 +22,18 @@ import (
 	"git.arilot.com/kuberstack/kuberstack-installer/protocol/gen/restapi/operations/installer"
 	"git.arilot.com/kuberstack/kuberstack-installer/protocol/responder"
 	"git.arilot.com/kuberstack/kuberstack-installer/savedstate"
+	"git.arilot.com/kuberstack/kuberstack-installer/steps"
 	"git.arilot.com/kuberstack/kuberstack-installer/steps/auth"
 	"git.arilot.com/kuberstack/kuberstack-installer/steps/aws"
 	"git.arilot.com/kuberstack/kuberstack-installer/steps/cluster"
+	"git.arilot.com/kuberstack/kuberstack-installer/steps/cluster/dnsprovider"
+	"git.arilot.com/kuberstack/kuberstack-installer/steps/cost"
 	"git.arilot.com/kuberstack/kuberstack-installer/steps/install"
+	"git.arilot.com/kuberstack/kuberstack-installer/steps/network"
 	"git.arilot.com/kuberstack/kuberstack-installer/steps/nodes"
 	"git.arilot.com/kuberstack/kuberstack-installer/steps/software"
+	"git.arilot.com/kuberstack/kuberstack-installer/steps/validation"
+	"git.arilot.com/kuberstack/kuberstack-installer/steps/versions"
 )
 
 var dbConfig struct {
@@ -34,7 +43,9 @@ var dbConfig struct {
 }
 
 var (
-	kopsConfig kops.Config
+	kopsConfig   kops.Config
+	dnsConfig    cluster.DNSConfig
+	imagesConfig nodes.ImagesConfig
 
 // 	kubectlConfig kubectl.Config
 )
@@ -54,6 +65,16 @@ func ConfigureFlags(api *operations.KuberstackInstallerAPI) {
 			LongDescription:  "Jus a way to run embedded kops binary",
 			Options:          &kopsConfig,
 		},
+		swag.CommandLineOptionsGroup{
+			ShortDescription: "DNS options",
+			LongDescription:  "Nameservers used to check the new zone delegation",
+			Options:          &dnsConfig,
+		},
+		swag.CommandLineOptionsGroup{
+			ShortDescription: "Images options",
+			LongDescription:  "Machine images the instances are run with",
+			Options:          &imagesConfig,
+		},
 	)
 }
 
@@ -66,6 +87,8 @@ func ConfigureAPI(api *operations.KuberstackInstallerAPI) {
 		os.Exit(code)
 	}
 
+	kopsExecutor := kops.NewSelfExecutor(cmdItself)
+
 	logger.Info("Started", "AuthExpire", dbConfig.AuthExpire)
 
 	conn, err := db.Open(dbConfig.Driver, dbConfig.URI, dbConfig.AuthExpire)
@@ -87,6 +110,11 @@ func ConfigureAPI(api *operations.KuberstackInstallerAPI) {
 		return tokenAuth(conn, token)
 	}
 
+	// the event stream clients pass the token in the query, EventSource can not set the header
+	api.APIKeyQueryAuth = func(token string) (interface{}, error) {
+		return tokenAuth(conn, token)
+	}
+
 	api.InstallerGetSessionIDHandler = installer.GetSessionIDHandlerFunc(
 		func(
 			params installer.GetSessionIDParams,
@@ -141,6 +169,23 @@ func ConfigureAPI(api *operations.KuberstackInstallerAPI) {
 		},
 	)
 
+	api.InstallerValidateSpecHandler = installer.ValidateSpecHandlerFunc(
+		func(
+			params installer.ValidateSpecParams,
+			principal interface{},
+		) middleware.Responder {
+			errs := validation.Validate(principal.(*savedstate.Principal).Sess)
+
+			return responder.OK(
+				&models.ValidateSpecOKBody{
+					Status: true,
+					Valid:  len(errs) == 0,
+					Errors: fieldErrorsModel(errs),
+				},
+			)
+		},
+	)
+
 	api.InstallerGetClusterTypesHandler = installer.GetClusterTypesHandlerFunc(
 		func(
 			params installer.GetClusterTypesParams,
@@ -149,7 +194,53 @@ func ConfigureAPI(api *operations.KuberstackInstallerAPI) {
 			return responder.OK(
 				&models.GetClusterTypesOKBody{
 					Status: true,
-					Types:  cluster.GetTypes(),
+					Types:  cluster.GetTypes(principal.(*savedstate.Principal).Sess.Region),
+				},
+			)
+		},
+	)
+
+	api.InstallerGetCostEstimateHandler = installer.GetCostEstimateHandlerFunc(
+		func(
+			params installer.GetCostEstimateParams,
+			principal interface{},
+		) middleware.Responder {
+			est, err := cost.EstimateState(principal.(*savedstate.Principal).Sess)
+			if err != nil {
+				return responder.NotOK(err.Error())
+			}
+
+			resp := models.GetCostEstimateOKBody{
+				Status:   true,
+				Region:   est.Region,
+				Currency: est.Currency,
+				Total:    fmt.Sprintf(cluster.PriceFormat, est.Total),
+				Items:    make([]*models.CostItem, len(est.Items)),
+			}
+
+			for i, item := range est.Items {
+				resp.Items[i] = &models.CostItem{
+					Name:      item.Name,
+					Quantity:  item.Quantity,
+					Unit:      item.Unit,
+					UnitPrice: fmt.Sprintf(cost.UnitPriceFormat, item.UnitPrice),
+					Monthly:   fmt.Sprintf(cluster.PriceFormat, item.Monthly),
+				}
+			}
+
+			return responder.OK(resp)
+		},
+	)
+
+	api.InstallerGetDNSProvidersHandler = installer.GetDNSProvidersHandlerFunc(
+		func(
+			params installer.GetDNSProvidersParams,
+			principal interface{},
+		) middleware.Responder {
+			return responder.OK(
+				&models.GetDNSProvidersOKBody{
+					Status:    true,
+					Providers: dnsprovider.Names,
 				},
 			)
 		},
@@ -197,16 +288,35 @@ func ConfigureAPI(api *operations.KuberstackInstallerAPI) {
 			params installer.CheckClusterValidityParams,
 			principal interface{},
 		) middleware.Responder {
-			err := cluster.CheckDomain(
+			store := cluster.StateStore{}
+			if params.Body.StateStore != nil {
+				store = cluster.StateStore{
+					Bucket:          params.Body.StateStore.Bucket,
+					Prefix:          params.Body.StateStore.Prefix,
+					KMSKeyID:        params.Body.StateStore.KmsKeyID,
+					OldVersionsDays: params.Body.StateStore.OldVersionsDays,
+				}
+			}
+
+			changes, err := cluster.CheckDomain(
 				conn,
 				awsSdk.StringValue(params.Body.Domain),
 				awsSdk.StringValue(params.Body.Name),
+				params.Body.DNSProvider,
+				store,
+				params.Body.Tags,
 				*(principal.(*savedstate.Principal)),
+				dnsConfig,
 			)
 			if err != nil {
 				return responder.NotOK(err.Error())
 			}
-			return responder.SimpleOK()
+			return responder.OK(
+				&models.CheckClusterValidityOKBody{
+					Status:     true,
+					DNSChanges: dnsChangesModel(changes),
+				},
+			)
 		},
 	)
 
@@ -215,16 +325,17 @@ func ConfigureAPI(api *operations.KuberstackInstallerAPI) {
 			params installer.CheckDNSInSyncParams,
 			principal interface{},
 		) middleware.Responder {
-			inSync, err := cluster.IsDNSInSync(
-				*(principal.(*savedstate.Principal)),
-			)
+			principalItself := *(principal.(*savedstate.Principal))
+
+			inSync, err := cluster.IsDNSInSync(principalItself, dnsConfig)
 			if err != nil {
 				return responder.NotOK(err.Error())
 			}
 			return responder.OK(
 				&models.CheckDNSInSyncOKBody{
-					Status: true,
-					Insync: inSync,
+					Status:      true,
+					Insync:      inSync,
+					Nameservers: principalItself.Sess.ZoneNSes,
 				},
 			)
 		})
@@ -234,12 +345,45 @@ func ConfigureAPI(api *operations.KuberstackInstallerAPI) {
 			params installer.GetNodesTypesParams,
 			principal interface{},
 		) middleware.Responder {
-			return responder.OK(
-				&models.GetNodesTypesOKBody{
-					Status:     true,
-					NodesTypes: nodes.GetNodeTypes(),
-				},
-			)
+			filter := nodes.TypeFilter{}
+			if params.MinCPU != nil {
+				filter.MinCPU = *params.MinCPU
+			}
+			if params.MinMemory != nil {
+				filter.MinMemory = *params.MinMemory
+			}
+			if params.Role != nil {
+				filter.Role = *params.Role
+			}
+			filter.Zones = params.Zones
+			if params.Architecture != nil {
+				filter.Architecture = *params.Architecture
+			}
+
+			region := principal.(*savedstate.Principal).Sess.Region
+
+			var offerings nodes.Offerings
+			if region != "" {
+				var err error
+				offerings, err = nodes.GetOfferings(region, *(principal.(*savedstate.Principal)))
+				if err != nil {
+					return responder.NotOK(err.Error())
+				}
+			}
+
+			catalog := nodes.GetCatalog(region, offerings, filter)
+
+			resp := models.GetNodesTypesOKBody{
+				Status:     true,
+				NodesTypes: make(models.StringArray, len(catalog)),
+				Types:      make([]*models.InstanceTypeInfo, len(catalog)),
+			}
+			for i, entry := range catalog {
+				resp.NodesTypes[i] = entry.Name
+				resp.Types[i] = instanceTypeModel(entry)
+			}
+
+			return responder.OK(&resp)
 		},
 	)
 
@@ -251,7 +395,7 @@ func ConfigureAPI(api *operations.KuberstackInstallerAPI) {
 			return responder.OK(
 				&models.GetStorageTypesOKBody{
 					Status:       true,
-					StorageTypes: nodes.GetNodeTypes(),
+					StorageTypes: nodes.GetVolumeTypes(),
 				},
 			)
 		},
@@ -266,12 +410,255 @@ func ConfigureAPI(api *operations.KuberstackInstallerAPI) {
 			if err != nil {
 				return responder.NotOK(err.Error())
 			}
-			return responder.OK(
-				&models.GetZonesListOKBody{
-					Status: true,
-					Zones:  zones,
+
+			offerings, err := nodes.GetOfferings(params.Region, *(principal.(*savedstate.Principal)))
+			if err != nil {
+				return responder.NotOK(err.Error())
+			}
+
+			resp := models.GetZonesListOKBody{
+				Status:    true,
+				Zones:     zones,
+				Offerings: make([]*models.ZoneOfferings, len(zones)),
+			}
+			for i, zone := range zones {
+				resp.Offerings[i] = &models.ZoneOfferings{
+					Zone:          zone,
+					InstanceTypes: offerings.Types(zone),
+				}
+			}
+
+			return responder.OK(&resp)
+		},
+	)
+
+	api.InstallerGetImagesHandler = installer.GetImagesHandlerFunc(
+		func(
+			params installer.GetImagesParams,
+			principal interface{},
+		) middleware.Responder {
+			arch := nodes.ArchX86
+			if params.InstanceType != nil {
+				arch = nodes.Architecture(*params.InstanceType)
+			}
+
+			images, err := nodes.GetImages(
+				principal.(*savedstate.Principal).Sess.Region,
+				arch,
+				*(principal.(*savedstate.Principal)),
+				imagesConfig,
+			)
+			if err != nil {
+				return responder.NotOK(err.Error())
+			}
+
+			resp := models.GetImagesOKBody{
+				Status: true,
+				Images: make([]*models.MachineImage, len(images)),
+			}
+			for i, image := range images {
+				resp.Images[i] = &models.MachineImage{
+					ID:           image.Curated,
+					ImageID:      image.ID,
+					ImageName:    image.Name,
+					Architecture: image.Architecture,
+					Hardened:     image.Hardened,
+				}
+			}
+
+			return responder.OK(&resp)
+		},
+	)
+
+	api.InstallerGetVpcsHandler = installer.GetVpcsHandlerFunc(
+		func(
+			params installer.GetVpcsParams,
+			principal interface{},
+		) middleware.Responder {
+			vpcs, err := network.GetVPCs(*(principal.(*savedstate.Principal)))
+			if err != nil {
+				return responder.NotOK(err.Error())
+			}
+
+			resp := models.GetVpcsOKBody{
+				Status: true,
+				Vpcs:   make([]*models.Vpc, len(vpcs)),
+			}
+			for i, vpc := range vpcs {
+				resp.Vpcs[i] = &models.Vpc{
+					ID:        vpc.ID,
+					Cidr:      vpc.CIDR,
+					Name:      vpc.Name,
+					IsDefault: vpc.IsDefault,
+				}
+			}
+
+			return responder.OK(&resp)
+		},
+	)
+
+	api.InstallerGetSubnetsHandler = installer.GetSubnetsHandlerFunc(
+		func(
+			params installer.GetSubnetsParams,
+			principal interface{},
+		) middleware.Responder {
+			subnets, err := network.GetSubnets(params.VpcID, *(principal.(*savedstate.Principal)))
+			if err != nil {
+				return responder.NotOK(err.Error())
+			}
+
+			resp := models.GetSubnetsOKBody{
+				Status:  true,
+				Subnets: make([]*models.Subnet, len(subnets)),
+			}
+			for i, subnet := range subnets {
+				resp.Subnets[i] = &models.Subnet{
+					ID:           subnet.ID,
+					Zone:         subnet.Zone,
+					Cidr:         subnet.CIDR,
+					Name:         subnet.Name,
+					Public:       subnet.Public,
+					AvailableIps: subnet.AvailableIPs,
+				}
+			}
+
+			return responder.OK(&resp)
+		},
+	)
+
+	api.InstallerSaveNetworkHandler = installer.SaveNetworkHandlerFunc(
+		func(
+			params installer.SaveNetworkParams,
+			principal interface{},
+		) middleware.Responder {
+			err := network.Save(
+				conn,
+				params.Body.VpcID,
+				params.Body.Subnets,
+				*(principal.(*savedstate.Principal)),
+			)
+			if err != nil {
+				return responder.NotOK(err.Error())
+			}
+			return responder.SimpleOK()
+		},
+	)
+
+	api.InstallerGetNetworkingProvidersHandler = installer.GetNetworkingProvidersHandlerFunc(
+		func(
+			params installer.GetNetworkingProvidersParams,
+			principal interface{},
+		) middleware.Responder {
+			resp := models.GetNetworkingProvidersOKBody{
+				Status:    true,
+				Providers: make([]*models.NetworkingProvider, len(network.NetworkingProviders)),
+			}
+			for i, provider := range network.NetworkingProviders {
+				resp.Providers[i] = &models.NetworkingProvider{
+					Name:            provider.Name,
+					NetworkPolicies: provider.NetworkPolicies,
+					PrivateTopology: provider.Private,
+					Default:         provider.Name == network.DefaultNetworking,
+				}
+			}
+
+			return responder.OK(&resp)
+		},
+	)
+
+	api.InstallerSaveNetworkingHandler = installer.SaveNetworkingHandlerFunc(
+		func(
+			params installer.SaveNetworkingParams,
+			principal interface{},
+		) middleware.Responder {
+			err := network.SaveNetworking(conn, params.Body.Networking, *(principal.(*savedstate.Principal)))
+			if err != nil {
+				return responder.NotOK(err.Error())
+			}
+			return responder.SimpleOK()
+		},
+	)
+
+	api.InstallerGetVersionsHandler = installer.GetVersionsHandlerFunc(
+		func(
+			params installer.GetVersionsParams,
+			principal interface{},
+		) middleware.Responder {
+			resp := models.GetVersionsOKBody{
+				Status:         true,
+				Versions:       make([]*models.KubernetesVersion, len(predefined.Versions.Versions)),
+				Channels:       predefined.Versions.Channels,
+				DefaultChannel: predefined.Versions.DefaultChannel,
+			}
+			for i, version := range predefined.Versions.Versions {
+				resp.Versions[i] = &models.KubernetesVersion{
+					Version: version.Version,
+					Default: version.Default,
+				}
+			}
+
+			return responder.OK(&resp)
+		},
+	)
+
+	api.InstallerSaveVersionHandler = installer.SaveVersionHandlerFunc(
+		func(
+			params installer.SaveVersionParams,
+			principal interface{},
+		) middleware.Responder {
+			err := versions.Save(
+				conn,
+				params.Body.KubernetesVersion,
+				params.Body.Channel,
+				*(principal.(*savedstate.Principal)),
+			)
+			if err != nil {
+				return responder.NotOK(err.Error())
+			}
+			return responder.SimpleOK()
+		},
+	)
+
+	api.InstallerSaveAccessHandler = installer.SaveAccessHandlerFunc(
+		func(
+			params installer.SaveAccessParams,
+			principal interface{},
+		) middleware.Responder {
+			err := network.SaveAccess(
+				conn,
+				savedstate.AccessParams{
+					Authorization:   params.Body.Authorization,
+					APICIDRs:        params.Body.APICidrs,
+					SSHCIDRs:        params.Body.SSHCidrs,
+					APILoadBalancer: params.Body.APILoadBalancer,
 				},
+				*(principal.(*savedstate.Principal)),
 			)
+			if err != nil {
+				return responder.NotOK(err.Error())
+			}
+			return responder.SimpleOK()
+		},
+	)
+
+	api.InstallerSaveBastionHandler = installer.SaveBastionHandlerFunc(
+		func(
+			params installer.SaveBastionParams,
+			principal interface{},
+		) middleware.Responder {
+			err := network.SaveBastion(
+				conn,
+				params.Body.Enabled,
+				savedstate.BastionParams{
+					Type:    params.Body.InstanceType,
+					DNSName: params.Body.DNSName,
+				},
+				*(principal.(*savedstate.Principal)),
+			)
+			if err != nil {
+				return responder.NotOK(err.Error())
+			}
+			return responder.SimpleOK()
 		},
 	)
 
@@ -285,6 +672,64 @@ func ConfigureAPI(api *operations.KuberstackInstallerAPI) {
 				*params.Body.Master,
 				*params.Body.Nodes,
 				*(principal.(*savedstate.Principal)),
+				imagesConfig,
+			)
+			if err != nil {
+				return responder.NotOK(err.Error())
+			}
+			return responder.SimpleOK()
+		},
+	)
+
+	api.InstallerGetGroupsHandler = installer.GetGroupsHandlerFunc(
+		func(
+			params installer.GetGroupsParams,
+			principal interface{},
+		) middleware.Responder {
+			return responder.OK(
+				&models.GetGroupsOKBody{
+					Status: true,
+					Groups: instanceGroupsModel(principal.(*savedstate.Principal).Sess.Groups),
+				},
+			)
+		},
+	)
+
+	api.InstallerSaveGroupHandler = installer.SaveGroupHandlerFunc(
+		func(
+			params installer.SaveGroupParams,
+			principal interface{},
+		) middleware.Responder {
+			err := install.SaveGroup(
+				conn,
+				*(principal.(*savedstate.Principal)),
+				nodes.ToInstanceGroup(*params.Body),
+				imagesConfig,
+				kopsExecutor,
+				kopsConfig.TmpDir,
+				kopsConfig.Timeout,
+				logger,
+			)
+			if err != nil {
+				return responder.NotOK(err.Error())
+			}
+			return responder.SimpleOK()
+		},
+	)
+
+	api.InstallerDeleteGroupHandler = installer.DeleteGroupHandlerFunc(
+		func(
+			params installer.DeleteGroupParams,
+			principal interface{},
+		) middleware.Responder {
+			err := install.DeleteGroup(
+				conn,
+				*(principal.(*savedstate.Principal)),
+				awsSdk.StringValue(params.Body.Name),
+				kopsExecutor,
+				kopsConfig.TmpDir,
+				kopsConfig.Timeout,
+				logger,
 			)
 			if err != nil {
 				return responder.NotOK(err.Error())
@@ -355,7 +800,7 @@ func ConfigureAPI(api *operations.KuberstackInstallerAPI) {
 			err := install.Install(
 				conn,
 				principalItself,
-				cmdItself,
+				kopsExecutor,
 				kopsConfig.TmpDir,
 				kopsConfig.Timeout,
 				logger,
@@ -374,8 +819,10 @@ func ConfigureAPI(api *operations.KuberstackInstallerAPI) {
 			principal interface{},
 		) middleware.Responder {
 			length, current := install.GetStatus(
+				params.HTTPRequest.Context(),
+				conn,
 				*(principal.(*savedstate.Principal)),
-				cmdItself,
+				kopsExecutor,
 				kopsConfig.TmpDir,
 				kopsConfig.Timeout,
 				logger,
@@ -398,12 +845,40 @@ func ConfigureAPI(api *operations.KuberstackInstallerAPI) {
 		) middleware.Responder {
 			principalItself := *(principal.(*savedstate.Principal))
 
+			// the instances are not there until the cluster is created, so the lookup error is not fatal
+			sshInstances, err := network.SSHInstances(principalItself)
+			if err != nil {
+				logger.PrintErr("SSH instances lookup error", "err", err)
+			}
+
 			resp := models.InstallOKBody{
-				Status:   true,
-				Domain:   principalItself.Sess.Domain,
-				Name:     principalItself.Sess.Name,
-				Software: make(models.InstallOKBodySoftware, len(principalItself.Sess.Products)),
-				Bucketid: principalItself.Sess.Bucket,
+				Status:            true,
+				Domain:            principalItself.Sess.Domain,
+				Name:              principalItself.Sess.Name,
+				Software:          make(models.InstallOKBodySoftware, len(principalItself.Sess.Products)),
+				Bucketid:          principalItself.Sess.Bucket,
+				StateStore:        principalItself.Sess.StateStore(),
+				Tags:              principalItself.Sess.Tags,
+				Network:           networkModel(principalItself.Sess.Network),
+				Topology:          principalItself.Sess.Topology(),
+				Networking:        network.Networking(principalItself.Sess),
+				KubernetesVersion: principalItself.Sess.KubernetesVersion,
+				ExportTarget:      principalItself.Sess.ExportTarget,
+				Channel:           principalItself.Sess.Channel,
+				Bastion:           bastionModel(principalItself.Sess.Bastion),
+				Access: &models.AccessRequest{
+					Authorization:   network.Authorization(principalItself.Sess),
+					APICidrs:        network.APIAccess(principalItself.Sess),
+					SSHCidrs:        network.SSHAccess(principalItself.Sess),
+					APILoadBalancer: network.APILoadBalancer(principalItself.Sess),
+				},
+				SSH: &models.SSHDetails{
+					Host:      network.SSHHost(principalItself.Sess),
+					Port:      network.SSHPort,
+					Bastion:   principalItself.Sess.Bastion != nil,
+					Cidrs:     network.SSHAccess(principalItself.Sess),
+					Instances: sshInstancesModel(sshInstances),
+				},
 				Master: &models.NodesProperties{
 					Instances: principalItself.Sess.Master.Quantity,
 					Zones:     principalItself.Sess.Master.Zones,
@@ -412,6 +887,7 @@ func ConfigureAPI(api *operations.KuberstackInstallerAPI) {
 					Instances: principalItself.Sess.Nodes.Quantity,
 					Zones:     principalItself.Sess.Nodes.Zones,
 				},
+				Groups: instanceGroupsModel(principalItself.Sess.Groups),
 			}
 
 			for ri, productID := range principalItself.Sess.Products {
@@ -436,6 +912,130 @@ func ConfigureAPI(api *operations.KuberstackInstallerAPI) {
 		},
 	)
 
+	api.InstallerExportClusterHandler = installer.ExportClusterHandlerFunc(
+		func(
+			params installer.ExportClusterParams,
+			principal interface{},
+		) middleware.Responder {
+			name, archive, err := install.Export(
+				conn,
+				*(principal.(*savedstate.Principal)),
+				params.Target,
+				kopsExecutor,
+				kopsConfig.TmpDir,
+				kopsConfig.Timeout,
+				logger,
+			)
+			if err != nil {
+				return responder.NotOK(err.Error())
+			}
+
+			return responder.NewFileResponder(name, "application/gzip", archive)
+		},
+	)
+
+	api.InstallerGetClusterManifestHandler = installer.GetClusterManifestHandlerFunc(
+		func(
+			params installer.GetClusterManifestParams,
+			principal interface{},
+		) middleware.Responder {
+			format := awsSdk.StringValue(params.Format)
+
+			manifest, err := install.GetClusterManifest(
+				params.HTTPRequest.Context(),
+				conn,
+				*(principal.(*savedstate.Principal)),
+				format,
+				kopsExecutor,
+				kopsConfig.TmpDir,
+				kopsConfig.Timeout,
+				logger,
+			)
+			if err != nil {
+				return responder.NotOK(err.Error())
+			}
+
+			return responder.OK(&models.ManifestOKBody{Status: true, Manifest: manifest, Format: manifestFormat(format)})
+		},
+	)
+
+	api.InstallerSaveClusterManifestHandler = installer.SaveClusterManifestHandlerFunc(
+		func(
+			params installer.SaveClusterManifestParams,
+			principal interface{},
+		) middleware.Responder {
+			diff, err := install.SaveClusterManifest(
+				params.HTTPRequest.Context(),
+				conn,
+				*(principal.(*savedstate.Principal)),
+				awsSdk.StringValue(params.Body.Manifest),
+				params.Body.Format,
+				params.Body.DryRun,
+				kopsExecutor,
+				kopsConfig.TmpDir,
+				kopsConfig.Timeout,
+				logger,
+			)
+			if err != nil {
+				return responder.NotOK(err.Error())
+			}
+
+			return responder.OK(&models.ManifestDiffOKBody{Status: true, Diff: diff, Applied: !params.Body.DryRun && diff != ""})
+		},
+	)
+
+	api.InstallerGetGroupManifestHandler = installer.GetGroupManifestHandlerFunc(
+		func(
+			params installer.GetGroupManifestParams,
+			principal interface{},
+		) middleware.Responder {
+			format := awsSdk.StringValue(params.Format)
+
+			manifest, err := install.GetGroupManifest(
+				params.HTTPRequest.Context(),
+				conn,
+				*(principal.(*savedstate.Principal)),
+				params.Name,
+				format,
+				kopsExecutor,
+				kopsConfig.TmpDir,
+				kopsConfig.Timeout,
+				logger,
+			)
+			if err != nil {
+				return responder.NotOK(err.Error())
+			}
+
+			return responder.OK(&models.ManifestOKBody{Status: true, Manifest: manifest, Format: manifestFormat(format)})
+		},
+	)
+
+	api.InstallerSaveGroupManifestHandler = installer.SaveGroupManifestHandlerFunc(
+		func(
+			params installer.SaveGroupManifestParams,
+			principal interface{},
+		) middleware.Responder {
+			diff, err := install.SaveGroupManifest(
+				params.HTTPRequest.Context(),
+				conn,
+				*(principal.(*savedstate.Principal)),
+				params.Body.Name,
+				awsSdk.StringValue(params.Body.Manifest),
+				params.Body.Format,
+				params.Body.DryRun,
+				kopsExecutor,
+				kopsConfig.TmpDir,
+				kopsConfig.Timeout,
+				logger,
+			)
+			if err != nil {
+				return responder.NotOK(err.Error())
+			}
+
+			return responder.OK(&models.ManifestDiffOKBody{Status: true, Diff: diff, Applied: !params.Body.DryRun && diff != ""})
+		},
+	)
+
 	api.InstallerInstallVanishHandler = installer.InstallVanishHandlerFunc(
 		func(
 			params installer.InstallVanishParams,
@@ -446,7 +1046,7 @@ func ConfigureAPI(api *operations.KuberstackInstallerAPI) {
 			err := install.Vanish(
 				conn,
 				principalItself,
-				cmdItself,
+				kopsExecutor,
 				kopsConfig.TmpDir,
 				kopsConfig.Timeout,
 				logger,
@@ -459,6 +1059,76 @@ func ConfigureAPI(api *operations.KuberstackInstallerAPI) {
 		},
 	)
 
+	api.InstallerGetLogsHandler = installer.GetLogsHandlerFunc(
+		func(
+			params installer.GetLogsParams,
+			principal interface{},
+		) middleware.Responder {
+			after := uint64(awsSdk.Int64Value(params.After))
+
+			entries, err := install.GetLogs(
+				conn,
+				*(principal.(*savedstate.Principal)),
+				after,
+				awsSdk.Int64Value(params.Limit),
+				awsSdk.StringValue(params.Job),
+			)
+			if err != nil {
+				return responder.NotOK(err.Error())
+			}
+
+			if len(entries) > 0 {
+				after = entries[len(entries)-1].Seq
+			}
+
+			return responder.OK(
+				&models.LogsOKBody{
+					Status:  true,
+					Entries: logEntriesModel(entries),
+					Next:    int64(after),
+				},
+			)
+		},
+	)
+
+	api.InstallerStreamLogsHandler = installer.StreamLogsHandlerFunc(
+		func(
+			params installer.StreamLogsParams,
+			principal interface{},
+		) middleware.Responder {
+			after := uint64(awsSdk.Int64Value(params.After))
+			if lastEventID := awsSdk.StringValue(params.LastEventID); lastEventID != "" {
+				seq, err := strconv.ParseUint(lastEventID, 10, 64)
+				if err != nil {
+					return responder.NotOK(fmt.Sprintf("Invalid Last-Event-ID: %q", lastEventID))
+				}
+				after = seq
+			}
+
+			return responder.NewEventStream(
+				params.HTTPRequest.Context(),
+				func(ctx context.Context, send func(responder.Event) error) error {
+					return install.StreamLogs(
+						ctx,
+						conn,
+						*(principal.(*savedstate.Principal)),
+						after,
+						func(entry savedstate.LogEntry) error {
+							return send(
+								responder.Event{
+									ID:   strconv.FormatUint(entry.Seq, 10),
+									Name: "log",
+									Data: logEntryModel(entry),
+								},
+							)
+						},
+						logger,
+					)
+				},
+			)
+		},
+	)
+
 }
 
 // GlobalMiddleware is a log-enabled HTTP Handler wrapper
@@ -478,7 +1148,7 @@ func (m GlobalMiddleware) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
 			rw.Header().Set("Access-Control-Allow-Origin", origin)
 			rw.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
 			rw.Header().Set("Access-Control-Allow-Headers",
-				"Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-Api-Key")
+				"Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-Api-Key, Last-Event-ID")
 		}
 		// Stop here if its Preflighted OPTIONS request
 		if r.Method == "OPTIONS" {
@@ -524,3 +1194,188 @@ func tokenAuth(conn db.Connect, token string) (interface{}, error) {
 
 	return principal, nil
 }
+
+func dnsChangesModel(changes []dnsprovider.Change) []*models.DNSChange {
+	res := make([]*models.DNSChange, 0, len(changes))
+	for _, change := range changes {
+		res = append(
+			res,
+			&models.DNSChange{
+				Action:  change.Action,
+				Zone:    change.Zone,
+				Comment: change.Comment,
+				Records: change.Records,
+			},
+		)
+	}
+	return res
+}
+
+func sshInstancesModel(instances []network.SSHInstance) []*models.SSHInstance {
+	res := make([]*models.SSHInstance, 0, len(instances))
+	for _, instance := range instances {
+		res = append(
+			res,
+			&models.SSHInstance{
+				InstanceGroup: instance.Group,
+				Master:        instance.Master,
+				Address:       instance.Address,
+			},
+		)
+	}
+	return res
+}
+
+func fieldErrorsModel(errs steps.FieldErrors) []*models.FieldError {
+	res := make([]*models.FieldError, 0, len(errs))
+	for _, e := range errs {
+		res = append(
+			res,
+			&models.FieldError{
+				Field:   e.Field,
+				Code:    e.Code,
+				Message: e.Message,
+			},
+		)
+	}
+	return res
+}
+
+func instanceGroupsModel(groups []savedstate.InstanceGroup) []*models.InstanceGroup {
+	res := make([]*models.InstanceGroup, 0, len(groups))
+	for _, group := range groups {
+		res = append(
+			res,
+			&models.InstanceGroup{
+				Name:         group.Name,
+				InstanceType: group.Type,
+				Instances:    group.Quantity,
+				MaxInstances: group.MaxSize,
+				Zones:        group.Zones,
+				StorageSize:  group.StorageSize,
+				StorageType:  group.StorageType,
+				Labels:       group.Labels,
+				Taints:       group.Taints,
+				Spot:         spotOptionsModel(group.Spot),
+
+				StorageIops:       group.StorageIOPS,
+				StorageThroughput: group.StorageThroughput,
+				StorageEncrypted:  group.StorageEncrypted,
+				StorageKmsKey:     group.StorageKMSKey,
+				Volumes:           dataVolumesModel(group.Volumes),
+				Image:             group.Image,
+			},
+		)
+	}
+	return res
+}
+
+func spotOptionsModel(spot *savedstate.SpotParams) *models.SpotOptions {
+	if spot == nil {
+		return nil
+	}
+	return &models.SpotOptions{
+		MaxPrice:      spot.MaxPrice,
+		InstanceTypes: spot.Types,
+		OnDemandBase:  spot.OnDemandBase,
+	}
+}
+
+func instanceTypeModel(entry nodes.CatalogEntry) *models.InstanceTypeInfo {
+	res := &models.InstanceTypeInfo{
+		Name:               entry.Name,
+		Vcpu:               entry.VCPU,
+		Memory:             entry.Memory,
+		Architecture:       entry.Architecture,
+		NetworkPerformance: entry.Network,
+		EbsOptimized:       entry.EBSOptimized,
+		Gpus:               entry.GPUs,
+		Generation:         entry.Generation,
+		Zones:              entry.Zones,
+	}
+	if entry.Price != 0 {
+		res.Price = fmt.Sprintf(cost.UnitPriceFormat, entry.Price)
+	}
+	return res
+}
+
+func networkModel(params *savedstate.NetworkParams) *models.NetworkProperties {
+	if params == nil {
+		return nil
+	}
+
+	res := &models.NetworkProperties{
+		VpcID:   params.VPCID,
+		Cidr:    params.CIDR,
+		Subnets: make([]*models.Subnet, len(params.Subnets)),
+	}
+	for i, subnet := range params.Subnets {
+		res.Subnets[i] = &models.Subnet{
+			ID:   subnet.ID,
+			Zone: subnet.Zone,
+			Cidr: subnet.CIDR,
+		}
+	}
+	return res
+}
+
+func bastionModel(params *savedstate.BastionParams) *models.BastionRequest {
+	if params == nil {
+		return &models.BastionRequest{Enabled: false}
+	}
+	return &models.BastionRequest{
+		Enabled:      true,
+		InstanceType: params.Type,
+		DNSName:      params.DNSName,
+	}
+}
+
+func manifestFormat(format string) string {
+	if format == "" {
+		return install.FormatYAML
+	}
+	return format
+}
+
+func dataVolumesModel(volumes []savedstate.VolumeParams) []*models.DataVolume {
+	if len(volumes) == 0 {
+		return nil
+	}
+
+	res := make([]*models.DataVolume, 0, len(volumes))
+	for _, volume := range volumes {
+		res = append(
+			res,
+			&models.DataVolume{
+				Device:     volume.Device,
+				Size:       volume.Size,
+				Type:       volume.Type,
+				Iops:       volume.IOPS,
+				Throughput: volume.Throughput,
+				Encrypted:  volume.Encrypted,
+				KmsKey:     volume.KMSKey,
+				Path:       volume.Path,
+			},
+		)
+	}
+	return res
+}
+
+func logEntriesModel(entries []savedstate.LogEntry) []*models.LogEntry {
+	res := make([]*models.LogEntry, 0, len(entries))
+	for _, entry := range entries {
+		res = append(res, logEntryModel(entry))
+	}
+	return res
+}
+
+func logEntryModel(entry savedstate.LogEntry) *models.LogEntry {
+	return &models.LogEntry{
+		Seq:    int64(entry.Seq),
+		Time:   entry.Time.Format(time.RFC3339Nano),
+		Job:    entry.Job,
+		Step:   entry.Step,
+		Stream: entry.Stream,
+		Line:   entry.Line,
+	}
+}
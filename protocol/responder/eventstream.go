@@ -0,0 +1,113 @@
+package responder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-openapi/runtime"
+)
+
+// keepAlive is the interval of the comments sent to keep the idle stream open
+const keepAlive = 15 * time.Second
+
+// Event is a server-sent event, the data is sent as JSON
+type Event struct {
+	ID   string
+	Name string
+	Data interface{}
+}
+
+// EventStreamResponder is a server-sent events stream wrapper
+// to be integrated with swagger generated code
+type EventStreamResponder struct {
+	ctx    context.Context
+	stream func(ctx context.Context, send func(Event) error) error
+}
+
+// NewEventStream creates an event stream responder,
+// the stream sends the events until it returns or the request context is done
+func NewEventStream(
+	ctx context.Context,
+	stream func(ctx context.Context, send func(Event) error) error,
+) *EventStreamResponder {
+	return &EventStreamResponder{ctx: ctx, stream: stream}
+}
+
+// WriteResponse is an actual responce write function
+func (r *EventStreamResponder) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+	flusher, ok := rw.(http.Flusher)
+	if !ok {
+		panic(fmt.Errorf("Streaming is not supported by the response writer"))
+	}
+
+	rw.Header().Set("Content-Type", "text/event-stream")
+	rw.Header().Set("Cache-Control", "no-cache")
+	rw.Header().Set("Connection", "keep-alive")
+
+	rw.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ctx, cancel := context.WithCancel(r.ctx)
+	defer cancel()
+
+	// closed stops the keep-alive writes once the response is done
+	var (
+		mu     sync.Mutex
+		closed bool
+	)
+	defer func() {
+		mu.Lock()
+		defer mu.Unlock()
+
+		closed = true
+	}()
+
+	write := func(content string) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if closed {
+			return context.Canceled
+		}
+
+		_, err := fmt.Fprint(rw, content)
+		if err != nil {
+			cancel()
+			return err
+		}
+		flusher.Flush()
+		return nil
+	}
+
+	go func() {
+		ticker := time.NewTicker(keepAlive)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				_ = write(": keep-alive\n\n")
+			}
+		}
+	}()
+
+	err := r.stream(
+		ctx,
+		func(event Event) error {
+			data, err := json.Marshal(event.Data)
+			if err != nil {
+				return err
+			}
+			return write(fmt.Sprintf("id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Name, data))
+		},
+	)
+	if err != nil && ctx.Err() == nil {
+		_ = write(fmt.Sprintf("event: error\ndata: %q\n\n", err.Error()))
+	}
+}
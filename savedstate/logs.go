@@ -0,0 +1,17 @@
+package savedstate
+
+import "time"
+
+// LogEntry is a line of the kops output kept in the session history
+type LogEntry struct {
+	// Seq is the entry number within the session, it grows with every entry added
+	Seq  uint64
+	Time time.Time
+	// Job is the run of the flow calling kops, like install-1508412345123,
+	// Step is the kops command of the job, like Create or Update
+	Job  string
+	Step string
+	// Stream is stdout or stderr
+	Stream string
+	Line   string
+}
@@ -1,6 +1,10 @@
 package savedstate
 
-import "time"
+import (
+	"sort"
+	"strings"
+	"time"
+)
 
 // NodesParams are the parameters for the group of nodes
 type NodesParams struct {
@@ -9,8 +13,129 @@ type NodesParams struct {
 	Zones       []string
 	StorageSize int64
 	StorageType string
+	// MaxSize is a max number of instances, the group is of the fixed Quantity size if not set
+	MaxSize int64
+	// Spot options, the group is on-demand only if not set
+	Spot *SpotParams
+
+	// StorageIOPS and StorageThroughput (in MiB/s) are provisioned for the root volume,
+	// the volume type defaults are used if not set
+	StorageIOPS       int64
+	StorageThroughput int64
+	StorageEncrypted  bool
+	// StorageKMSKey is a KMS key to encrypt the root volume with, the default EBS key is used if not set
+	StorageKMSKey string
+	// Volumes are the additional data volumes attached to every instance
+	Volumes []VolumeParams
+
+	// Image is an AMI ID to run the instances with, kops picks its default image if not set
+	Image string
+}
+
+// VolumeParams are the parameters of an EBS volume
+type VolumeParams struct {
+	// Device is a device name the volume is attached as, like /dev/xvdf
+	Device     string
+	Size       int64
+	Type       string
+	IOPS       int64
+	Throughput int64
+	Encrypted  bool
+	KMSKey     string
+	// Path the volume is mounted to, the volume is left unformatted if not set
+	Path string
+}
+
+// RootVolume returns the root volume parameters of the group
+func (p NodesParams) RootVolume() VolumeParams {
+	return VolumeParams{
+		Size:       p.StorageSize,
+		Type:       p.StorageType,
+		IOPS:       p.StorageIOPS,
+		Throughput: p.StorageThroughput,
+		Encrypted:  p.StorageEncrypted,
+		KMSKey:     p.StorageKMSKey,
+	}
+}
+
+// HasStorageOptions checks if any of the storage options kops create has no flags for is set
+func (p NodesParams) HasStorageOptions() bool {
+	return p.StorageType != "" ||
+		p.StorageIOPS != 0 ||
+		p.StorageThroughput != 0 ||
+		p.StorageEncrypted ||
+		len(p.Volumes) > 0
+}
+
+// SpotParams are the spot options of the group of nodes
+type SpotParams struct {
+	// MaxPrice is a max hourly price for a spot instance, on-demand price is the limit if not set
+	MaxPrice float64
+	// Types are the instance types interchangeable with the group one
+	Types []string
+	// OnDemandBase is a number of on-demand instances to keep, the rest are spot ones
+	OnDemandBase int64
+}
+
+// InstanceGroup is an additional named group of nodes
+type InstanceGroup struct {
+	Name string
+	NodesParams
+
+	// Labels are the Kubernetes labels to be set on the group nodes
+	Labels map[string]string
+	// Taints are the Kubernetes taints in the key=value:Effect format
+	Taints []string
+}
+
+// NetworkParams are the existing VPC and subnets the cluster is deployed into
+type NetworkParams struct {
+	VPCID   string
+	CIDR    string
+	Subnets []SubnetParams
+}
+
+// SubnetParams is an existing subnet, one per zone
+type SubnetParams struct {
+	ID   string
+	Zone string
+	CIDR string
+}
+
+// SubnetIDs returns the IDs of the subnets
+func (n *NetworkParams) SubnetIDs() []string {
+	ids := make([]string, 0, len(n.Subnets))
+	for _, subnet := range n.Subnets {
+		ids = append(ids, subnet.ID)
+	}
+	return ids
+}
+
+// BastionParams are the bastion host options,
+// SSH access to the bastion is allowed from the access SSH CIDRs
+type BastionParams struct {
+	Type string
+	// DNSName is the bastion name label under the cluster domain
+	DNSName string
 }
 
+// AccessParams are the Kubernetes API and SSH access options, the defaults are used for the empty ones
+type AccessParams struct {
+	// Authorization is the API authorization mode, RBAC or AlwaysAllow
+	Authorization string
+	// APICIDRs and SSHCIDRs are the source addresses the API and SSH access is allowed from
+	APICIDRs []string
+	SSHCIDRs []string
+	// APILoadBalancer is the API load balancer type, public or internal
+	APILoadBalancer string
+}
+
+// kops network topologies
+const (
+	TopologyPublic  = "public"
+	TopologyPrivate = "private"
+)
+
 // State data struct as it will be stored in DB
 type State struct {
 	Ctime  time.Time
@@ -25,15 +150,88 @@ type State struct {
 	Domain      string
 	Name        string
 	Type        int64
+	DNSProvider string
 	ZoneID      string
+	ZoneNSes    []string
 	ZoneWatchID string
 	RecWatchID  string
-	Bucket      string
+
+	Bucket         string
+	BucketPrefix   string
+	BucketExisting bool
+
+	// Tags are the cost-allocation tags to be set on every AWS resource created
+	Tags map[string]string
+
+	// Network is an existing VPC shared with the cluster, kops creates a new one if not set
+	Network *NetworkParams
+	// Bastion is the SSH entry point of the private cluster, the cluster is public with no bastion
+	Bastion *BastionParams
+
+	// Access restricts the Kubernetes API and SSH access
+	Access AccessParams
+
+	// Networking is the pod networking provider, the default one is used if not set
+	Networking string
+
+	// KubernetesVersion and the kops Channel are pinned on install if not set
+	KubernetesVersion string
+	Channel           string
 
 	Master NodesParams
 	Nodes  NodesParams
+	Groups []InstanceGroup
 
 	Products []string
 
 	Kubecfg []byte
+	// ExportTarget is the kops target the cluster is exported to,
+	// the exported cluster is created by applying the code generated and not by the installer
+	ExportTarget string
+	// ExportSpecReady is set once the bastion, groups and addons are added to the exported cluster spec
+	ExportSpecReady bool
+}
+
+// ClusterName returns the cluster full domain name
+func (s *State) ClusterName() string {
+	return strings.TrimSuffix(s.Name+"."+s.Domain, ".")
+}
+
+// Topology returns the kops network topology,
+// the nodes are in the private subnets reachable through the bastion only if it is set
+func (s *State) Topology() string {
+	if s.Bastion != nil {
+		return TopologyPrivate
+	}
+	return TopologyPublic
+}
+
+// StateStore returns the kops state store URL
+func (s *State) StateStore() string {
+	prefix := strings.Trim(s.BucketPrefix, "/")
+	if prefix == "" {
+		return "s3://" + s.Bucket
+	}
+	return "s3://" + s.Bucket + "/" + prefix
+}
+
+// CloudLabels returns the tags in the kops --cloud-labels format
+func (s *State) CloudLabels() string {
+	labels := make([]string, 0, len(s.Tags))
+	for key, value := range s.Tags {
+		labels = append(labels, key+"="+value)
+	}
+	sort.Strings(labels)
+
+	return strings.Join(labels, ",")
+}
+
+// GetGroup returns an additional instance group by name or nil if there is no such group
+func (s *State) GetGroup(name string) *InstanceGroup {
+	for i := range s.Groups {
+		if s.Groups[i].Name == name {
+			return &s.Groups[i]
+		}
+	}
+	return nil
 }
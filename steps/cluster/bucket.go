@@ -0,0 +1,262 @@
+package cluster
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/powerman/structlog"
+
+	"git.arilot.com/kuberstack/kuberstack-installer/savedstate"
+
+	awsSdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+const (
+	defaultOldVersionsDays = 30
+	usEast1                = "us-east-1"
+)
+
+// StateStore holds the kops state store options requested by the user
+type StateStore struct {
+	// Bucket is an existing bucket to be used, a new one is created if empty
+	Bucket string
+	// Prefix is a path inside the bucket to keep the kops state under
+	Prefix string
+	// KMSKeyID is a KMS key to encrypt a new bucket with, AWS managed key used if empty
+	KMSKeyID string
+	// OldVersionsDays is a number of days to keep noncurrent object versions in a new bucket
+	OldVersionsDays int64
+}
+
+// bucketSteps returns the steps needed to get the state store bucket ready,
+// the bucket name is stored to the variable provided.
+// An existing bucket belongs to the user, so it is neither configured nor tagged.
+func bucketSteps(
+	sess client.ConfigProvider,
+	store StateStore,
+	tags map[string]string,
+	principal savedstate.Principal,
+	clusterName string,
+	bucket *string,
+) []setupStep {
+	clnS3 := s3.New(sess)
+	logger := structlog.DefaultLogger
+
+	if store.Bucket != "" {
+		return []setupStep{
+			{
+				name: "S3 bucket",
+				do: func() (func() error, error) {
+					_, err := clnS3.HeadBucket(&s3.HeadBucketInput{Bucket: awsSdk.String(store.Bucket)})
+					if err != nil {
+						logger.PrintErr("Check bucket error", "bucket", store.Bucket, "err", err)
+						return nil, fmt.Errorf("S3 bucket is not accessible: %q", store.Bucket)
+					}
+					*bucket = store.Bucket
+					return nil, nil
+				},
+			},
+		}
+	}
+
+	idHash := sha256.Sum224([]byte(principal.ID))
+	*bucket = hex.EncodeToString(idHash[:])
+
+	setup := []setupStep{
+		{
+			name: "S3 bucket",
+			do: func() (func() error, error) {
+				created, err := createBucket(clnS3, *bucket, principal.Sess.Region)
+				if err != nil || !created {
+					return nil, err
+				}
+				logger.Info("S3 bucket created", "bucket", *bucket, "region", principal.Sess.Region)
+
+				return func() error { return deleteBucket(clnS3, *bucket) }, nil
+			},
+		},
+		{
+			name: "S3 bucket versioning",
+			do: func() (func() error, error) {
+				_, err := clnS3.PutBucketVersioning(
+					&s3.PutBucketVersioningInput{
+						Bucket: bucket,
+						VersioningConfiguration: &s3.VersioningConfiguration{
+							Status: awsSdk.String(s3.BucketVersioningStatusEnabled),
+						},
+					},
+				)
+				return nil, err
+			},
+		},
+		{
+			name: "S3 bucket encryption",
+			do: func() (func() error, error) {
+				sse := &s3.ServerSideEncryptionByDefault{
+					SSEAlgorithm: awsSdk.String(s3.ServerSideEncryptionAwsKms),
+				}
+				if store.KMSKeyID != "" {
+					sse.KMSMasterKeyID = awsSdk.String(store.KMSKeyID)
+				}
+
+				_, err := clnS3.PutBucketEncryption(
+					&s3.PutBucketEncryptionInput{
+						Bucket: bucket,
+						ServerSideEncryptionConfiguration: &s3.ServerSideEncryptionConfiguration{
+							Rules: []*s3.ServerSideEncryptionRule{
+								{ApplyServerSideEncryptionByDefault: sse},
+							},
+						},
+					},
+				)
+				return nil, err
+			},
+		},
+		{
+			name: "S3 bucket public access block",
+			do: func() (func() error, error) {
+				_, err := clnS3.PutPublicAccessBlock(
+					&s3.PutPublicAccessBlockInput{
+						Bucket: bucket,
+						PublicAccessBlockConfiguration: &s3.PublicAccessBlockConfiguration{
+							BlockPublicAcls:       awsSdk.Bool(true),
+							BlockPublicPolicy:     awsSdk.Bool(true),
+							IgnorePublicAcls:      awsSdk.Bool(true),
+							RestrictPublicBuckets: awsSdk.Bool(true),
+						},
+					},
+				)
+				return nil, err
+			},
+		},
+		{
+			name: "S3 bucket lifecycle",
+			do: func() (func() error, error) {
+				days := store.OldVersionsDays
+				if days <= 0 {
+					days = defaultOldVersionsDays
+				}
+
+				_, err := clnS3.PutBucketLifecycleConfiguration(
+					&s3.PutBucketLifecycleConfigurationInput{
+						Bucket: bucket,
+						LifecycleConfiguration: &s3.BucketLifecycleConfiguration{
+							Rules: []*s3.LifecycleRule{
+								{
+									ID:     awsSdk.String("expire-old-versions"),
+									Status: awsSdk.String(s3.ExpirationStatusEnabled),
+									Filter: &s3.LifecycleRuleFilter{Prefix: awsSdk.String("")},
+									NoncurrentVersionExpiration: &s3.NoncurrentVersionExpiration{
+										NoncurrentDays: awsSdk.Int64(days),
+									},
+									AbortIncompleteMultipartUpload: &s3.AbortIncompleteMultipartUpload{
+										DaysAfterInitiation: awsSdk.Int64(7),
+									},
+								},
+							},
+						},
+					},
+				)
+				return nil, err
+			},
+		},
+		{
+			name: "S3 bucket tags",
+			do: func() (func() error, error) {
+				tagSet := []*s3.Tag{
+					{Key: awsSdk.String("KubernetesCluster"), Value: awsSdk.String(strings.TrimSuffix(clusterName, "."))},
+					{Key: awsSdk.String("kuberstack.com/session"), Value: awsSdk.String(principal.ID)},
+				}
+				for _, key := range sortedKeys(tags) {
+					tagSet = append(tagSet, &s3.Tag{Key: awsSdk.String(key), Value: awsSdk.String(tags[key])})
+				}
+
+				_, err := clnS3.PutBucketTagging(
+					&s3.PutBucketTaggingInput{
+						Bucket:  bucket,
+						Tagging: &s3.Tagging{TagSet: tagSet},
+					},
+				)
+				return nil, err
+			},
+		},
+	}
+
+	// Bucket configuration errors are logged as is and reported to the user as internal ones
+	for i := range setup {
+		do := setup[i].do
+		name := setup[i].name
+		setup[i].do = func() (func() error, error) {
+			undo, err := do()
+			if err != nil {
+				logger.PrintErr("State store setup error", "step", name, "bucket", *bucket, "err", err)
+				return undo, fmt.Errorf("Internal server error")
+			}
+			return undo, nil
+		}
+	}
+
+	return setup
+}
+
+// codes HeadBucket reports with, the response has no body to get the detailed code from
+const (
+	errCodeNotFound  = "NotFound"
+	errCodeForbidden = "Forbidden"
+)
+
+// createBucket creates a new bucket in the region provided, created flag is not set
+// if the bucket was created by the same account before.
+// The bucket is checked first, us-east-1 reports no error on creating the bucket owned already.
+func createBucket(clnS3 *s3.S3, bucketName string, region string) (bool, error) {
+	_, err := clnS3.HeadBucket(&s3.HeadBucketInput{Bucket: awsSdk.String(bucketName)})
+	if err == nil {
+		return false, nil
+	}
+	awsErr, ok := err.(awserr.Error)
+	if !ok {
+		return false, err
+	}
+	switch awsErr.Code() {
+	case errCodeNotFound, s3.ErrCodeNoSuchBucket:
+	case errCodeForbidden:
+		// the bucket of another account, the create reports it taken
+	default:
+		return false, err
+	}
+
+	input := &s3.CreateBucketInput{
+		Bucket: awsSdk.String(bucketName),
+	}
+	// us-east-1 is the default location and it can not be set explicitly
+	if region != usEast1 {
+		input.CreateBucketConfiguration = &s3.CreateBucketConfiguration{
+			LocationConstraint: awsSdk.String(region),
+		}
+	}
+
+	_, err = clnS3.CreateBucket(input)
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == s3.ErrCodeBucketAlreadyOwnedByYou {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+func deleteBucket(clnS3 *s3.S3, bucketName string) error {
+	_, err := clnS3.DeleteBucket(
+		&s3.DeleteBucketInput{
+			Bucket: awsSdk.String(bucketName),
+		},
+	)
+
+	return err
+}
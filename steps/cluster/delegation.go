@@ -0,0 +1,70 @@
+package cluster
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/powerman/structlog"
+
+	"git.arilot.com/kuberstack/kuberstack-installer/steps/cluster/dnsprovider"
+
+	awsSdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+// DNSConfig is a command-line config for the DNS delegation check
+type DNSConfig struct {
+	Resolvers []string      `long:"dnsResolver" description:"Nameserver (host[:port]) to query for the NS records, parent zone nameservers are used for the delegation check and the system ones for the manual DNS provider if not set" env:"DNSRESOLVERS" env-delim:","`
+	Timeout   time.Duration `long:"dnsTimeout" description:"Max time a single DNS query allowed to take" default:"5s" env:"DNSTIMEOUT"`
+}
+
+// IsDelegated queries every server provided for the NS records of the name
+// and checks all of them answer with exactly the expected nameservers set
+func IsDelegated(
+	name string,
+	expected []string,
+	servers []string,
+	timeout time.Duration,
+) (bool, error) {
+	if len(servers) == 0 {
+		return false, fmt.Errorf("No nameservers to check the delegation of %q", name)
+	}
+
+	client := &dns.Client{Timeout: timeout}
+
+	for _, server := range servers {
+		got, err := queryNS(client, name, server)
+		if err != nil {
+			return false, err
+		}
+
+		if !dnsprovider.SameNameservers(got, expected) {
+			structlog.DefaultLogger.Debug("Delegation not in sync", "name", name, "server", server, "got", got, "expected", expected)
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func queryNS(client *dns.Client, name string, server string) ([]string, error) {
+	nses, rcode, err := dnsprovider.QueryNS(client, name, server)
+	if err != nil || rcode != dns.RcodeSuccess {
+		return nil, err
+	}
+
+	return nses, nil
+}
+
+func getZoneNSes(r53 *route53.Route53, zoneID string) ([]string, error) {
+	zone, err := r53.GetHostedZone(&route53.GetHostedZoneInput{Id: &zoneID})
+	if err != nil {
+		return nil, err
+	}
+	if zone.DelegationSet == nil {
+		return nil, fmt.Errorf("Zone has no delegation set: %q", zoneID)
+	}
+
+	return awsSdk.StringValueSlice(zone.DelegationSet.NameServers), nil
+}
@@ -0,0 +1,126 @@
+package cluster
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+const testTimeout = 2 * time.Second
+
+// startDNSServer runs a local UDP nameserver answering every NS query
+// for the name with the servers provided, in the answer or in the authority
+// (referral) section. The server address and a func to stop it are returned.
+func startDNSServer(t *testing.T, name string, servers []string, referral bool) (string, func()) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen error: %v", err)
+	}
+
+	handler := dns.HandlerFunc(func(w dns.ResponseWriter, req *dns.Msg) {
+		resp := new(dns.Msg)
+		resp.SetReply(req)
+
+		if req.Question[0].Qtype == dns.TypeNS && req.Question[0].Name == dns.Fqdn(name) {
+			for _, server := range servers {
+				rr := &dns.NS{
+					Hdr: dns.RR_Header{Name: dns.Fqdn(name), Rrtype: dns.TypeNS, Class: dns.ClassINET, Ttl: 300},
+					Ns:  dns.Fqdn(server),
+				}
+				if referral {
+					resp.Ns = append(resp.Ns, rr)
+				} else {
+					resp.Answer = append(resp.Answer, rr)
+				}
+			}
+		} else {
+			resp.SetRcode(req, dns.RcodeNameError)
+		}
+
+		_ = w.WriteMsg(resp)
+	})
+
+	started := make(chan struct{})
+	server := &dns.Server{PacketConn: pc, Handler: handler, NotifyStartedFunc: func() { close(started) }}
+	go func() { _ = server.ActivateAndServe() }()
+	<-started
+
+	return pc.LocalAddr().String(), func() { _ = server.Shutdown() }
+}
+
+func TestIsDelegated(t *testing.T) {
+	const name = "test.example.com"
+	zoneNSes := []string{"ns-1.awsdns-01.org.", "ns-2.awsdns-02.net."}
+
+	tests := []struct {
+		desc     string
+		name     string
+		servers  []string
+		referral bool
+		want     bool
+	}{
+		{"answer", name, zoneNSes, false, true},
+		{"referral", name, zoneNSes, true, true},
+		{"case and order", name, []string{"NS-2.AWSDNS-02.NET", "ns-1.awsdns-01.org"}, true, true},
+		{"missing server", name, zoneNSes[:1], true, false},
+		{"extra server", name, append([]string{"ns.other.com."}, zoneNSes...), true, false},
+		{"no records", "other.example.com", zoneNSes, true, false},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			resolver, stop := startDNSServer(t, tc.name, tc.servers, tc.referral)
+			defer stop()
+
+			config := DNSConfig{Resolvers: []string{resolver}, Timeout: testTimeout}
+
+			got, err := IsDelegated(name, zoneNSes, config.Resolvers, config.Timeout)
+			if err != nil {
+				t.Fatalf("IsDelegated error: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("IsDelegated = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestIsDelegatedAllServers(t *testing.T) {
+	const name = "test.example.com"
+	zoneNSes := []string{"ns-1.awsdns-01.org.", "ns-2.awsdns-02.net."}
+
+	inSync, stopInSync := startDNSServer(t, name, zoneNSes, true)
+	defer stopInSync()
+	stale, stopStale := startDNSServer(t, name, []string{"ns.old.com."}, true)
+	defer stopStale()
+
+	got, err := IsDelegated(name, zoneNSes, []string{inSync, stale}, testTimeout)
+	if err != nil {
+		t.Fatalf("IsDelegated error: %v", err)
+	}
+	if got {
+		t.Errorf("IsDelegated = true with one of the servers not in sync")
+	}
+}
+
+func TestIsDelegatedErrors(t *testing.T) {
+	_, err := IsDelegated("test.example.com", []string{"ns."}, nil, testTimeout)
+	if err == nil {
+		t.Errorf("No error for the empty servers list")
+	}
+
+	// nothing listens there, so the query times out
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen error: %v", err)
+	}
+	defer func() { _ = pc.Close() }()
+
+	_, err = IsDelegated("test.example.com", []string{"ns."}, []string{pc.LocalAddr().String()}, 100*time.Millisecond)
+	if err == nil {
+		t.Errorf("No error for the nameserver not answering")
+	}
+}
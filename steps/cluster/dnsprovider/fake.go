@@ -0,0 +1,155 @@
+package dnsprovider
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// Fake is an in-memory provider to be used in tests instead of the real DNS services
+type Fake struct {
+	// Zones maps a domain to its zone ID
+	Zones map[string]string
+	// Servers maps a zone ID to its nameservers
+	Servers map[string][]string
+	// Records maps a zone ID to the NS records (name to servers) in it
+	Records map[string]map[string][]string
+	// Pending holds the changes not in sync yet
+	Pending map[string]bool
+	// Errors maps a method name to the error it should return
+	Errors map[string]error
+
+	changes int
+
+	sync.Mutex
+}
+
+// NewFake creates a fake provider hosting the domains provided
+func NewFake(domains ...string) *Fake {
+	f := &Fake{
+		Zones:   make(map[string]string, len(domains)),
+		Servers: make(map[string][]string, len(domains)),
+		Records: make(map[string]map[string][]string, len(domains)),
+		Pending: make(map[string]bool),
+		Errors:  make(map[string]error),
+	}
+
+	for i, domain := range domains {
+		zoneID := "fake-zone-" + strconv.Itoa(i)
+		f.Zones[fqdn(domain)] = zoneID
+		f.Servers[zoneID] = []string{"ns1." + fqdn(domain), "ns2." + fqdn(domain)}
+		f.Records[zoneID] = make(map[string][]string)
+	}
+
+	return f
+}
+
+// ZoneID implements Provider
+func (f *Fake) ZoneID(domain string) (string, error) {
+	f.Lock()
+	defer f.Unlock()
+
+	return f.Zones[fqdn(domain)], f.Errors["ZoneID"]
+}
+
+// Nameservers implements Provider
+func (f *Fake) Nameservers(zoneID string) ([]string, error) {
+	f.Lock()
+	defer f.Unlock()
+
+	if err := f.Errors["Nameservers"]; err != nil {
+		return nil, err
+	}
+
+	servers, ok := f.Servers[zoneID]
+	if !ok {
+		return nil, fmt.Errorf("No such zone: %q", zoneID)
+	}
+
+	return servers, nil
+}
+
+// CheckRecordAvailability implements Provider
+func (f *Fake) CheckRecordAvailability(zoneID string, name string, servers []string) (bool, error) {
+	f.Lock()
+	defer f.Unlock()
+
+	if err := f.Errors["CheckRecordAvailability"]; err != nil {
+		return false, err
+	}
+
+	existing, ok := f.Records[zoneID][fqdn(name)]
+	if !ok {
+		return false, nil
+	}
+	if SameNameservers(existing, servers) {
+		return true, nil
+	}
+
+	return false, fmt.Errorf("Record already exists: %q", name)
+}
+
+// CreateDelegation implements Provider, the change created is pending
+// until Sync is called
+func (f *Fake) CreateDelegation(zoneID string, name string, servers []string, comment string) (string, error) {
+	f.Lock()
+	defer f.Unlock()
+
+	if err := f.Errors["CreateDelegation"]; err != nil {
+		return "", err
+	}
+
+	records, ok := f.Records[zoneID]
+	if !ok {
+		return "", fmt.Errorf("No such zone: %q", zoneID)
+	}
+	if _, ok := records[fqdn(name)]; ok {
+		return "", fmt.Errorf("Record already exists: %q", name)
+	}
+	records[fqdn(name)] = servers
+
+	f.changes++
+	changeID := "fake-change-" + strconv.Itoa(f.changes)
+	f.Pending[changeID] = true
+
+	return changeID, nil
+}
+
+// DeleteDelegation implements Provider
+func (f *Fake) DeleteDelegation(zoneID string, name string, servers []string) error {
+	f.Lock()
+	defer f.Unlock()
+
+	if err := f.Errors["DeleteDelegation"]; err != nil {
+		return err
+	}
+
+	existing, ok := f.Records[zoneID][fqdn(name)]
+	if !ok || !SameNameservers(existing, servers) {
+		return fmt.Errorf("No such record: %q", name)
+	}
+	delete(f.Records[zoneID], fqdn(name))
+
+	return nil
+}
+
+// IsChangeInSync implements Provider
+func (f *Fake) IsChangeInSync(changeID string) (bool, error) {
+	f.Lock()
+	defer f.Unlock()
+
+	return !f.Pending[changeID], f.Errors["IsChangeInSync"]
+}
+
+// ManualChanges implements Provider, the fake makes all the changes itself
+func (f *Fake) ManualChanges() []Change {
+	return nil
+}
+
+// Sync marks all the pending changes as done
+func (f *Fake) Sync() {
+	f.Lock()
+	defer f.Unlock()
+
+	f.Pending = make(map[string]bool)
+}
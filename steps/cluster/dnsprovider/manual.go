@@ -0,0 +1,153 @@
+package dnsprovider
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+const resolvConf = "/etc/resolv.conf"
+
+type manualProvider struct {
+	changes   []Change
+	resolvers []string
+	client    *dns.Client
+}
+
+// NewManual creates a provider for the parent domain hosted anywhere else.
+// The NS records to be added (removed) by the user are returned by ManualChanges,
+// the records themselves are checked by resolving them with the resolvers provided,
+// the system ones are used if there are none.
+func NewManual(resolvers []string, timeout time.Duration) Provider {
+	return &manualProvider{
+		resolvers: resolvers,
+		client:    &dns.Client{Timeout: timeout},
+	}
+}
+
+// ZoneID returns the domain itself, the user is the one in control of it
+func (p *manualProvider) ZoneID(domain string) (string, error) {
+	return fqdn(domain), nil
+}
+
+func (p *manualProvider) Nameservers(zoneID string) ([]string, error) {
+	nses, rcode, err := p.lookupNS(zoneID)
+	if err != nil {
+		return nil, err
+	}
+	if rcode != dns.RcodeSuccess {
+		return nil, fmt.Errorf("Error resolving nameservers of %q: %s", zoneID, dns.RcodeToString[rcode])
+	}
+
+	return nses, nil
+}
+
+func (p *manualProvider) CheckRecordAvailability(zoneID string, name string, servers []string) (bool, error) {
+	nses, rcode, err := p.lookupNS(name)
+	if err != nil {
+		return false, err
+	}
+	if rcode == dns.RcodeNameError {
+		return false, nil
+	}
+	if rcode != dns.RcodeSuccess {
+		return false, fmt.Errorf("Error resolving nameservers of %q: %s", name, dns.RcodeToString[rcode])
+	}
+	if len(nses) == 0 {
+		return false, nil
+	}
+	if SameNameservers(nses, servers) {
+		return true, nil
+	}
+
+	return false, fmt.Errorf("Record already exists: %q", name)
+}
+
+func (p *manualProvider) CreateDelegation(zoneID string, name string, servers []string, comment string) (string, error) {
+	p.changes = append(p.changes, nsRecords(ChangeAdd, zoneID, name, servers, comment))
+	return "", nil
+}
+
+// DeleteDelegation drops the records requested by CreateDelegation if the user
+// has not seen them yet, otherwise asks the user to remove them
+func (p *manualProvider) DeleteDelegation(zoneID string, name string, servers []string) error {
+	added := nsRecords(ChangeAdd, zoneID, name, servers, "")
+	for i, change := range p.changes {
+		if change.Action == added.Action && change.Zone == added.Zone && sameStrings(change.Records, added.Records) {
+			p.changes = append(p.changes[:i], p.changes[i+1:]...)
+			return nil
+		}
+	}
+
+	p.changes = append(p.changes, nsRecords(ChangeRemove, zoneID, name, servers, "no longer needed"))
+	return nil
+}
+
+// IsChangeInSync always reports true: there is no change to watch,
+// the delegation is checked by resolving the records
+func (p *manualProvider) IsChangeInSync(changeID string) (bool, error) {
+	return true, nil
+}
+
+func (p *manualProvider) ManualChanges() []Change {
+	return p.changes
+}
+
+// lookupNS queries the resolvers in turn for the NS records of the name,
+// the first one answered is used
+func (p *manualProvider) lookupNS(name string) ([]string, int, error) {
+	resolvers := p.resolvers
+	if len(resolvers) == 0 {
+		config, err := dns.ClientConfigFromFile(resolvConf)
+		if err != nil {
+			return nil, 0, fmt.Errorf("Error reading system resolvers: %v", err)
+		}
+		for _, server := range config.Servers {
+			resolvers = append(resolvers, net.JoinHostPort(server, config.Port))
+		}
+	}
+	if len(resolvers) == 0 {
+		return nil, 0, fmt.Errorf("No nameservers to resolve %q", name)
+	}
+
+	var err error
+	for _, resolver := range resolvers {
+		var nses []string
+		var rcode int
+		nses, rcode, err = QueryNS(p.client, name, resolver)
+		if err == nil {
+			return nses, rcode, nil
+		}
+	}
+
+	return nil, 0, err
+}
+
+func nsRecords(action string, zoneID string, name string, servers []string, comment string) Change {
+	change := Change{
+		Action:  action,
+		Zone:    zoneID,
+		Comment: comment,
+		Records: make([]string, 0, len(servers)),
+	}
+
+	for _, server := range servers {
+		change.Records = append(change.Records, fmt.Sprintf("%s\t%d\tIN\tNS\t%s", fqdn(name), int300, fqdn(server)))
+	}
+
+	return change
+}
+
+func sameStrings(s1 []string, s2 []string) bool {
+	if len(s1) != len(s2) {
+		return false
+	}
+	for i := range s1 {
+		if s1[i] != s2[i] {
+			return false
+		}
+	}
+	return true
+}
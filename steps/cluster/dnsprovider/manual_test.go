@@ -0,0 +1,138 @@
+package dnsprovider
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+func TestManualChanges(t *testing.T) {
+	p := NewManual(nil, time.Second)
+	servers := []string{"ns-1.awsdns-01.org", "ns-2.awsdns-02.net"}
+	records := []string{
+		"test.example.com.\t300\tIN\tNS\tns-1.awsdns-01.org.",
+		"test.example.com.\t300\tIN\tNS\tns-2.awsdns-02.net.",
+	}
+
+	if changes := p.ManualChanges(); len(changes) != 0 {
+		t.Fatalf("Changes before any delegation: %v", changes)
+	}
+
+	_, err := p.CreateDelegation("example.com.", "test.example.com", servers, "comment")
+	if err != nil {
+		t.Fatalf("CreateDelegation error: %v", err)
+	}
+
+	want := []Change{{Action: ChangeAdd, Zone: "example.com.", Comment: "comment", Records: records}}
+	if got := p.ManualChanges(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ManualChanges = %v, want %v", got, want)
+	}
+
+	// rolled back before the user has seen it
+	err = p.DeleteDelegation("example.com.", "test.example.com", servers)
+	if err != nil {
+		t.Fatalf("DeleteDelegation error: %v", err)
+	}
+	if changes := p.ManualChanges(); len(changes) != 0 {
+		t.Errorf("Changes after rollback: %v", changes)
+	}
+
+	// created by the user before
+	p = NewManual(nil, time.Second)
+	err = p.DeleteDelegation("example.com.", "test.example.com", servers)
+	if err != nil {
+		t.Fatalf("DeleteDelegation error: %v", err)
+	}
+	want = []Change{{Action: ChangeRemove, Zone: "example.com.", Comment: "no longer needed", Records: records}}
+	if got := p.ManualChanges(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ManualChanges = %v, want %v", got, want)
+	}
+}
+
+// startResolver serves NS records of the names provided,
+// the names listed in failed are answered with SERVFAIL, any other with NXDOMAIN
+func startResolver(t *testing.T, records map[string][]string, failed ...string) (string, func()) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen error: %v", err)
+	}
+
+	handler := dns.HandlerFunc(func(w dns.ResponseWriter, req *dns.Msg) {
+		resp := new(dns.Msg)
+		resp.SetReply(req)
+
+		q := req.Question[0]
+		servers, ok := records[q.Name]
+		switch {
+		case ok && q.Qtype == dns.TypeNS:
+			for _, server := range servers {
+				resp.Answer = append(resp.Answer, &dns.NS{
+					Hdr: dns.RR_Header{Name: q.Name, Rrtype: dns.TypeNS, Class: dns.ClassINET, Ttl: 300},
+					Ns:  dns.Fqdn(server),
+				})
+			}
+		case len(failed) > 0 && q.Name == dns.Fqdn(failed[0]):
+			resp.SetRcode(req, dns.RcodeServerFailure)
+		default:
+			resp.SetRcode(req, dns.RcodeNameError)
+		}
+
+		_ = w.WriteMsg(resp)
+	})
+
+	started := make(chan struct{})
+	server := &dns.Server{PacketConn: pc, Handler: handler, NotifyStartedFunc: func() { close(started) }}
+	go func() { _ = server.ActivateAndServe() }()
+	<-started
+
+	return pc.LocalAddr().String(), func() { _ = server.Shutdown() }
+}
+
+func TestManualResolve(t *testing.T) {
+	servers := []string{"ns-1.awsdns-01.org.", "ns-2.awsdns-02.net."}
+	resolver, stop := startResolver(t, map[string][]string{
+		"example.com.":       {"ns1.example.net."},
+		"test.example.com.":  servers,
+		"other.example.com.": {"ns1.example.net."},
+	}, "broken.example.com")
+	defer stop()
+
+	p := NewManual([]string{resolver}, time.Second)
+
+	nses, err := p.Nameservers("example.com.")
+	if err != nil {
+		t.Fatalf("Nameservers error: %v", err)
+	}
+	if !reflect.DeepEqual(nses, []string{"ns1.example.net."}) {
+		t.Errorf("Nameservers = %v", nses)
+	}
+	if _, err = p.Nameservers("missing.com."); err == nil {
+		t.Errorf("Nameservers of missing zone: no error")
+	}
+
+	tests := []struct {
+		name    string
+		exists  bool
+		wantErr bool
+	}{
+		{"new.example.com", false, false},
+		{"test.example.com", true, false},
+		{"other.example.com", false, true},
+		{"broken.example.com", false, true},
+	}
+	for _, tc := range tests {
+		exists, err := p.CheckRecordAvailability("example.com.", tc.name, servers)
+		if exists != tc.exists || (err != nil) != tc.wantErr {
+			t.Errorf("CheckRecordAvailability(%q) = %v, %v, want %v, error %v", tc.name, exists, err, tc.exists, tc.wantErr)
+		}
+	}
+
+	// unreachable resolver is skipped
+	p = NewManual([]string{"127.0.0.1:1", resolver}, time.Second)
+	if exists, err := p.CheckRecordAvailability("example.com.", "test.example.com", servers); err != nil || !exists {
+		t.Errorf("CheckRecordAvailability with fallback = %v, %v", exists, err)
+	}
+}
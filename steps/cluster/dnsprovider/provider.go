@@ -0,0 +1,112 @@
+// Package dnsprovider holds the DNS services able to host the parent domain of the cluster
+package dnsprovider
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/client"
+)
+
+// Names of the providers known
+const (
+	Route53 = "route53"
+	Manual  = "manual"
+)
+
+// Names is a list of the providers to be offered to the user
+var Names = []string{Route53, Manual}
+
+// Provider is a DNS service hosting the parent domain.
+// The cluster zone itself is always a Route53 one,
+// so the provider is used only to delegate the cluster subdomain to it.
+type Provider interface {
+	// ZoneID returns the provider specific ID of the zone hosting the domain,
+	// empty string is returned if the domain is not hosted by the provider
+	ZoneID(domain string) (string, error)
+
+	// Nameservers returns the authoritative nameservers of the zone
+	Nameservers(zoneID string) ([]string, error)
+
+	// CheckRecordAvailability checks there is no record with the name in the zone.
+	// NS record pointing to the servers provided is not a conflict,
+	// the exists flag is returned for it instead of an error.
+	CheckRecordAvailability(zoneID string, name string, servers []string) (bool, error)
+
+	// CreateDelegation creates NS records for the name in the zone.
+	// The change ID to be watched with IsChangeInSync is returned, it may be empty.
+	CreateDelegation(zoneID string, name string, servers []string, comment string) (string, error)
+
+	// DeleteDelegation removes the NS records created with CreateDelegation
+	DeleteDelegation(zoneID string, name string, servers []string) error
+
+	// IsChangeInSync checks the change returned by CreateDelegation is applied
+	IsChangeInSync(changeID string) (bool, error)
+
+	// ManualChanges returns the changes requested so far the user has to make by hand,
+	// nil for the providers making the changes themselves
+	ManualChanges() []Change
+}
+
+// Change actions
+const (
+	ChangeAdd    = "add"
+	ChangeRemove = "remove"
+)
+
+// Change is a set of records to be added to (removed from) the zone by the user
+type Change struct {
+	Action  string
+	Zone    string
+	Comment string
+	// Records are in the zone file format
+	Records []string
+}
+
+// New creates a provider by name, Route53 is used if the name is empty.
+// The resolvers are used by the providers checking the records by resolving them.
+func New(name string, sess client.ConfigProvider, resolvers []string, timeout time.Duration) (Provider, error) {
+	switch name {
+	case Route53, "":
+		return NewRoute53(sess), nil
+	case Manual:
+		return NewManual(resolvers, timeout), nil
+	default:
+		return nil, fmt.Errorf("Unsupported DNS provider %q", name)
+	}
+}
+
+// SameNameservers checks two lists of nameservers are the same regardless of order,
+// case and trailing dots
+func SameNameservers(s1 []string, s2 []string) bool {
+	s1 = normalizeNSes(s1)
+	s2 = normalizeNSes(s2)
+
+	if len(s1) != len(s2) {
+		return false
+	}
+	for i := range s1 {
+		if s1[i] != s2[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func normalizeNSes(nses []string) []string {
+	res := make([]string, 0, len(nses))
+	for _, ns := range nses {
+		res = append(res, fqdn(strings.ToLower(ns)))
+	}
+	sort.Strings(res)
+	return res
+}
+
+func fqdn(name string) string {
+	if !strings.HasSuffix(name, ".") {
+		name += "."
+	}
+	return name
+}
@@ -0,0 +1,44 @@
+package dnsprovider
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+const dnsPort = "53"
+
+// QueryNS asks the server for the NS records of the name.
+// The response code is returned as is, the records are collected
+// for the successful response only.
+func QueryNS(client *dns.Client, name string, server string) ([]string, int, error) {
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, dnsPort)
+	}
+
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(name), dns.TypeNS)
+
+	resp, _, err := client.Exchange(msg, server)
+	if err != nil {
+		return nil, 0, fmt.Errorf("Error querying nameserver %q for %q: %v", server, name, err)
+	}
+	if resp.Rcode != dns.RcodeSuccess {
+		return nil, resp.Rcode, nil
+	}
+
+	// Parent zone servers answer with a referral, so the NS set
+	// is in the authority section rather than in the answer one
+	nses := make([]string, 0, len(resp.Answer)+len(resp.Ns))
+	for _, rr := range append(resp.Answer, resp.Ns...) {
+		ns, ok := rr.(*dns.NS)
+		if !ok || !strings.EqualFold(ns.Hdr.Name, dns.Fqdn(name)) {
+			continue
+		}
+		nses = append(nses, ns.Ns)
+	}
+
+	return nses, resp.Rcode, nil
+}
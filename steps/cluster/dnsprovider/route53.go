@@ -0,0 +1,153 @@
+package dnsprovider
+
+import (
+	"fmt"
+
+	awsSdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+var (
+	num1str   = "1"
+	int300    = int64(300)
+	strCREATE = "CREATE"
+	strDELETE = "DELETE"
+	strNS     = "NS"
+)
+
+type route53Provider struct {
+	r53 *route53.Route53
+}
+
+// NewRoute53 creates a provider for the parent domain hosted by Route53
+// in the same AWS account
+func NewRoute53(sess client.ConfigProvider) Provider {
+	return &route53Provider{r53: route53.New(sess)}
+}
+
+func (p *route53Provider) ZoneID(domain string) (string, error) {
+	domain = fqdn(domain)
+
+	zones, err := p.r53.ListHostedZonesByName(
+		&route53.ListHostedZonesByNameInput{
+			DNSName:  &domain,
+			MaxItems: &num1str,
+		},
+	)
+	if err != nil {
+		return "", err
+	}
+
+	if len(zones.HostedZones) == 0 || *zones.HostedZones[0].Name != domain {
+		return "", nil
+	}
+
+	return *zones.HostedZones[0].Id, nil
+}
+
+func (p *route53Provider) Nameservers(zoneID string) ([]string, error) {
+	zone, err := p.r53.GetHostedZone(&route53.GetHostedZoneInput{Id: &zoneID})
+	if err != nil {
+		return nil, err
+	}
+	if zone.DelegationSet == nil {
+		return nil, fmt.Errorf("Zone has no delegation set: %q", zoneID)
+	}
+
+	return awsSdk.StringValueSlice(zone.DelegationSet.NameServers), nil
+}
+
+func (p *route53Provider) CheckRecordAvailability(zoneID string, name string, servers []string) (bool, error) {
+	recs, err := p.r53.ListResourceRecordSets(
+		&route53.ListResourceRecordSetsInput{
+			HostedZoneId:    &zoneID,
+			StartRecordName: &name,
+			MaxItems:        &num1str,
+		},
+	)
+	if err != nil {
+		return false, err
+	}
+	if len(recs.ResourceRecordSets) == 0 || *recs.ResourceRecordSets[0].Name != name {
+		return false, nil
+	}
+
+	rec := recs.ResourceRecordSets[0]
+	if awsSdk.StringValue(rec.Type) == strNS {
+		values := make([]string, 0, len(rec.ResourceRecords))
+		for _, rr := range rec.ResourceRecords {
+			values = append(values, awsSdk.StringValue(rr.Value))
+		}
+		if SameNameservers(values, servers) {
+			return true, nil
+		}
+	}
+
+	return false, fmt.Errorf("Record already exists: %q", name)
+}
+
+func (p *route53Provider) CreateDelegation(zoneID string, name string, servers []string, comment string) (string, error) {
+	res, err := p.r53.ChangeResourceRecordSets(
+		&route53.ChangeResourceRecordSetsInput{
+			HostedZoneId: &zoneID,
+			ChangeBatch: &route53.ChangeBatch{
+				Comment: awsSdk.String(comment),
+				Changes: []*route53.Change{nsChange(strCREATE, name, servers)},
+			},
+		},
+	)
+	if err != nil {
+		return "", err
+	}
+
+	return awsSdk.StringValue(res.ChangeInfo.Id), nil
+}
+
+func (p *route53Provider) DeleteDelegation(zoneID string, name string, servers []string) error {
+	_, err := p.r53.ChangeResourceRecordSets(
+		&route53.ChangeResourceRecordSetsInput{
+			HostedZoneId: &zoneID,
+			ChangeBatch: &route53.ChangeBatch{
+				Changes: []*route53.Change{nsChange(strDELETE, name, servers)},
+			},
+		},
+	)
+
+	return err
+}
+
+func (p *route53Provider) IsChangeInSync(changeID string) (bool, error) {
+	if changeID == "" {
+		return true, nil
+	}
+
+	status, err := p.r53.GetChange(&route53.GetChangeInput{Id: &changeID})
+	if err != nil {
+		return false, err
+	}
+
+	return awsSdk.StringValue(status.ChangeInfo.Status) == route53.ChangeStatusInsync, nil
+}
+
+// ManualChanges implements Provider, all the changes are made by Route53 itself
+func (p *route53Provider) ManualChanges() []Change {
+	return nil
+}
+
+func nsChange(action string, name string, servers []string) *route53.Change {
+	nsRecs := make([]*route53.ResourceRecord, 0, len(servers))
+	for _, server := range servers {
+		nsRecs = append(nsRecs, &route53.ResourceRecord{Value: awsSdk.String(fqdn(server))})
+	}
+
+	return &route53.Change{
+		Action: &action,
+		ResourceRecordSet: &route53.ResourceRecordSet{
+			Name:            &name,
+			Type:            &strNS,
+			TTL:             &int300,
+			ResourceRecords: nsRecs,
+		},
+	}
+}
@@ -1,8 +1,6 @@
 package cluster
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
 	"fmt"
 	"strings"
 
@@ -12,29 +10,41 @@ import (
 	"git.arilot.com/kuberstack/kuberstack-installer/db"
 	"git.arilot.com/kuberstack/kuberstack-installer/savedstate"
 	"git.arilot.com/kuberstack/kuberstack-installer/steps"
+	"git.arilot.com/kuberstack/kuberstack-installer/steps/cluster/dnsprovider"
+	"git.arilot.com/kuberstack/kuberstack-installer/steps/validation"
 
 	awsSdk "github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/client"
 	"github.com/aws/aws-sdk-go/service/route53"
-	"github.com/aws/aws-sdk-go/service/s3"
 )
 
 var (
 	num1str        = "1"
 	listDomainsMax = "10000"
-	int300         = int64(300)
-	strCREATE      = "CREATE"
-	strNS          = "NS"
 )
 
 // CheckDomain check the new domain name uniqueness
 // and creates a new hosted zone in case it dows not exists yet.
+// All the resources are created as a sequence of steps: in the case any step failed
+// the things created before are removed, so no orphaned resources left.
+// The resources created by the same principal before are reused,
+// so it is safe to call it again after a failure.
+// The DNS changes the user has to make by hand (if any) are returned.
 func CheckDomain(
 	conn db.Connect,
 	domain string,
 	name string,
+	dnsProviderName string,
+	store StateStore,
+	tags map[string]string,
 	principal savedstate.Principal,
-) error {
+	dnsConfig DNSConfig,
+) ([]dnsprovider.Change, error) {
+	errs := validation.ValidateName(name, domain)
+	errs = append(errs, validation.ValidateTags(tags)...)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
 	domain, name, newName := fixNames(domain, name)
 
 	sess, err := steps.AwsSession(
@@ -43,54 +53,127 @@ func CheckDomain(
 		principal.Sess.Region,
 	)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	r53 := route53.New(sess)
+	logger := structlog.DefaultLogger
 
-	domZoneID, err := getZoneID(r53, domain)
+	provider, err := dnsprovider.New(dnsProviderName, sess, dnsConfig.Resolvers, dnsConfig.Timeout)
 	if err != nil {
-		return err
-	}
-	if domZoneID == "" {
-		return fmt.Errorf("Domain is out of control: %q", domain)
+		return nil, err
 	}
 
-	err = checkRecordAvailability(r53, domZoneID, newName)
+	domZoneID, err := provider.ZoneID(domain)
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	zoneID, zoneNSes, zoneWatchID, err := createZone(r53, newName, principal.ID)
-	if err != nil {
-		return err
+	if domZoneID == "" {
+		return nil, fmt.Errorf("Domain is out of control: %q", domain)
 	}
-	structlog.DefaultLogger.Info("Zone created", "name", newName, "id", zoneID, "ns", zoneNSes, "watch", zoneWatchID)
 
-	recWatchID, err := createNSRecords(r53, domZoneID, newName, zoneNSes, principal.ID)
-	if err != nil {
-		structlog.DefaultLogger.PrintErr(err)
-		return fmt.Errorf("Unexpected error creating NS records for %q", newName)
+	var (
+		zoneID      string
+		zoneNSes    []string
+		zoneWatchID string
+		recWatchID  string
+		bucket      string
+	)
+
+	setup := []setupStep{
+		{
+			name: "hosted zone",
+			do: func() (func() error, error) {
+				var created bool
+				var err error
+
+				zoneID, zoneNSes, zoneWatchID, created, err = ensureZone(r53, newName, principal)
+				if err != nil || !created {
+					return nil, err
+				}
+				logger.Info("Zone created", "name", newName, "id", zoneID, "ns", zoneNSes, "watch", zoneWatchID)
+
+				return func() error { return deleteZone(r53, zoneID) }, nil
+			},
+		},
+		{
+			name: "hosted zone tags",
+			do: func() (func() error, error) {
+				return nil, tagZone(r53, zoneID, tags)
+			},
+		},
+		delegationStep(provider, domZoneID, newName, &zoneNSes, principal, &recWatchID, logger),
 	}
-	structlog.DefaultLogger.Info("NS records created", "name", newName, "ns", zoneNSes, "watch", recWatchID)
 
-	principal.Sess.Name = name
-	principal.Sess.Domain = domain
-	principal.Sess.ZoneID = zoneID
-	principal.Sess.ZoneWatchID = zoneWatchID
-	principal.Sess.RecWatchID = recWatchID
+	setup = append(setup, bucketSteps(sess, store, tags, principal, newName, &bucket)...)
+
+	setup = append(
+		setup,
+		setupStep{
+			name: "state",
+			do: func() (func() error, error) {
+				principal.Sess.Name = name
+				principal.Sess.Domain = domain
+				principal.Sess.DNSProvider = dnsProviderName
+				principal.Sess.ZoneID = zoneID
+				principal.Sess.ZoneNSes = zoneNSes
+				principal.Sess.ZoneWatchID = zoneWatchID
+				principal.Sess.RecWatchID = recWatchID
+				principal.Sess.Bucket = bucket
+				principal.Sess.BucketPrefix = store.Prefix
+				principal.Sess.BucketExisting = store.Bucket != ""
+				principal.Sess.Tags = tags
+
+				return nil, conn.SaveState(principal.ID, principal.Sess)
+			},
+		},
+	)
 
-	principal.Sess.Bucket, err = createBucket(sess, principal.ID, newName)
+	err = runSetup(setup, logger)
 	if err != nil {
-		structlog.DefaultLogger.PrintErr("Create bucket error", "bucket", principal.Sess.Bucket, "err", err)
-		return fmt.Errorf("Internal server error")
+		return nil, err
 	}
-	structlog.DefaultLogger.Info("S3 bucket created", "bucket", principal.Sess.Bucket)
 
-	return conn.SaveState(principal.ID, principal.Sess)
+	return provider.ManualChanges(), nil
 }
 
-func getZoneID(r53 *route53.Route53, name string) (string, error) {
+// delegationStep creates the NS records delegating the cluster zone in the parent domain zone.
+// The records created by the same principal before are reused.
+func delegationStep(
+	provider dnsprovider.Provider,
+	domZoneID string,
+	newName string,
+	zoneNSes *[]string,
+	principal savedstate.Principal,
+	recWatchID *string,
+	logger *structlog.Logger,
+) setupStep {
+	return setupStep{
+		name: "NS records",
+		do: func() (func() error, error) {
+			exists, err := provider.CheckRecordAvailability(domZoneID, newName, *zoneNSes)
+			if err != nil {
+				return nil, err
+			}
+			if exists {
+				*recWatchID = principal.Sess.RecWatchID
+				return nil, nil
+			}
+
+			*recWatchID, err = provider.CreateDelegation(domZoneID, newName, *zoneNSes, zoneComment(principal.ID))
+			if err != nil {
+				logger.PrintErr(err)
+				return nil, fmt.Errorf("Unexpected error creating NS records for %q", newName)
+			}
+			logger.Info("NS records created", "name", newName, "ns", *zoneNSes, "watch", *recWatchID)
+
+			servers := *zoneNSes
+			return func() error { return provider.DeleteDelegation(domZoneID, newName, servers) }, nil
+		},
+	}
+}
+
+func getZone(r53 *route53.Route53, name string) (*route53.HostedZone, error) {
 	zones, err := r53.ListHostedZonesByName(
 		&route53.ListHostedZonesByNameInput{
 			DNSName:  &name,
@@ -98,33 +181,62 @@ func getZoneID(r53 *route53.Route53, name string) (string, error) {
 		},
 	)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	if len(zones.HostedZones) == 0 || *zones.HostedZones[0].Name != name {
-		return "", nil
+		return nil, nil
 	}
 
-	return *zones.HostedZones[0].Id, nil
+	return zones.HostedZones[0], nil
 }
 
-func createZone(r53 *route53.Route53, name string, principal string) (string, []string, string, error) {
-	zoneID, err := getZoneID(r53, name)
+func zoneComment(principal string) string {
+	return fmt.Sprintf("Created as part of Kuberstack installation: %q", principal)
+}
+
+// ensureZone creates a new hosted zone or returns the one created by the same principal before.
+// The created flag is set only if the zone was created by this call.
+func ensureZone(
+	r53 *route53.Route53,
+	name string,
+	principal savedstate.Principal,
+) (string, []string, string, bool, error) {
+	zone, err := getZone(r53, name)
 	if err != nil {
-		return "", nil, "", err
+		return "", nil, "", false, err
+	}
+
+	if zone == nil {
+		zoneID, zoneNSes, zoneWatchID, err := createZone(r53, name, principal.ID)
+		return zoneID, zoneNSes, zoneWatchID, err == nil, err
 	}
-	if zoneID != "" {
-		return "", nil, "", fmt.Errorf("Zone already exists: %q", name)
+
+	if zone.Config == nil || awsSdk.StringValue(zone.Config.Comment) != zoneComment(principal.ID) {
+		return "", nil, "", false, fmt.Errorf("Zone already exists: %q", name)
 	}
 
+	zoneID := awsSdk.StringValue(zone.Id)
+	zoneNSes, err := getZoneNSes(r53, zoneID)
+	if err != nil {
+		return "", nil, "", false, err
+	}
+
+	zoneWatchID := ""
+	if zoneID == principal.Sess.ZoneID {
+		zoneWatchID = principal.Sess.ZoneWatchID
+	}
+
+	return zoneID, zoneNSes, zoneWatchID, false, nil
+}
+
+func createZone(r53 *route53.Route53, name string, principal string) (string, []string, string, error) {
 	res, err := r53.CreateHostedZone(
 		&route53.CreateHostedZoneInput{
 			Name:            &name,
 			CallerReference: awsSdk.String(uuid.NewV4().String()),
 			HostedZoneConfig: &route53.HostedZoneConfig{
-				Comment: awsSdk.String(
-					fmt.Sprintf("Created as part of Kuberstack installation: %q", principal),
-				),
+				Comment: awsSdk.String(zoneComment(principal)),
 			},
 		},
 	)
@@ -138,39 +250,9 @@ func createZone(r53 *route53.Route53, name string, principal string) (string, []
 		nil
 }
 
-func createNSRecords(
-	r53 *route53.Route53,
-	zoneID string,
-	name string,
-	servers []string,
-	principal string,
-) (string, error) {
-	recCreated, err := r53.ChangeResourceRecordSets(
-		&route53.ChangeResourceRecordSetsInput{
-			HostedZoneId: &zoneID,
-			ChangeBatch: &route53.ChangeBatch{
-				Comment: awsSdk.String(
-					fmt.Sprintf("Created as part of Kuberstack installation: %q", principal),
-				),
-				Changes: []*route53.Change{
-					&route53.Change{
-						Action: &strCREATE,
-						ResourceRecordSet: &route53.ResourceRecordSet{
-							Name:            &name,
-							Type:            &strNS,
-							TTL:             &int300,
-							ResourceRecords: prepareNSRecords(servers),
-						},
-					},
-				},
-			},
-		},
-	)
-	if err != nil {
-		return "", err
-	}
-
-	return awsSdk.StringValue(recCreated.ChangeInfo.Id), nil
+func deleteZone(r53 *route53.Route53, zoneID string) error {
+	_, err := r53.DeleteHostedZone(&route53.DeleteHostedZoneInput{Id: &zoneID})
+	return err
 }
 
 // GetDomains returns a list of domais registered for the corresponding account
@@ -221,40 +303,11 @@ func fixNames(domain string, name string) (string, string, string) {
 	return domain, name, newName
 }
 
-func checkRecordAvailability(r53 *route53.Route53, zoneID string, name string) error {
-	recs, err := r53.ListResourceRecordSets(
-		&route53.ListResourceRecordSetsInput{
-			HostedZoneId:    &zoneID,
-			StartRecordName: &name,
-			MaxItems:        &num1str,
-		},
-	)
-	if err != nil {
-		return err
-	}
-	if len(recs.ResourceRecordSets) > 0 && *recs.ResourceRecordSets[0].Name == name {
-		return fmt.Errorf("Record already exists: %q", name)
-	}
-
-	return nil
-}
-
-func prepareNSRecords(servers []string) []*route53.ResourceRecord {
-	nsRecs := make([]*route53.ResourceRecord, 0, len(servers))
-
-	for _, server := range servers {
-		if !strings.HasSuffix(server, ".") {
-			server += "."
-		}
-		nsRecs = append(nsRecs, &route53.ResourceRecord{Value: awsSdk.String(server)})
-	}
-
-	return nsRecs
-}
-
 // IsDNSInSync checks propagation status for the previously created zone and record
+// and makes sure the parent domain nameservers really delegate to the new zone
 func IsDNSInSync(
 	principal savedstate.Principal,
+	dnsConfig DNSConfig,
 ) (bool, error) {
 	sess, err := steps.AwsSession(
 		principal.Sess.AccessKey,
@@ -267,49 +320,57 @@ func IsDNSInSync(
 
 	r53 := route53.New(sess)
 
-	status, err := r53.GetChange(&route53.GetChangeInput{Id: &principal.Sess.ZoneWatchID})
+	provider, err := dnsprovider.New(principal.Sess.DNSProvider, sess, dnsConfig.Resolvers, dnsConfig.Timeout)
 	if err != nil {
 		return false, err
 	}
-	if awsSdk.StringValue(status.ChangeInfo.Status) != "INSYNC" {
-		return false, nil
+
+	inSync, err := isChangeInSync(r53, principal.Sess.ZoneWatchID)
+	if err != nil || !inSync {
+		return false, err
 	}
 
-	status, err = r53.GetChange(&route53.GetChangeInput{Id: &principal.Sess.RecWatchID})
-	if err != nil {
+	inSync, err = provider.IsChangeInSync(principal.Sess.RecWatchID)
+	if err != nil || !inSync {
 		return false, err
 	}
-	if awsSdk.StringValue(status.ChangeInfo.Status) != "INSYNC" {
-		return false, nil
+
+	zoneNSes, err := getZoneNSes(r53, principal.Sess.ZoneID)
+	if err != nil {
+		return false, err
 	}
 
-	return true, nil
-}
+	domain, _, newName := fixNames(principal.Sess.Domain, principal.Sess.Name)
 
-func createBucket(sess client.ConfigProvider, id string, domain string) (string, error) {
-	clnS3 := s3.New(sess)
+	servers := dnsConfig.Resolvers
+	if len(servers) == 0 {
+		domZoneID, err := provider.ZoneID(domain)
+		if err != nil {
+			return false, err
+		}
+		if domZoneID == "" {
+			return false, fmt.Errorf("Domain is out of control: %q", domain)
+		}
+		servers, err = provider.Nameservers(domZoneID)
+		if err != nil {
+			return false, err
+		}
+	}
 
-	idHash := sha256.Sum224([]byte(id))
+	return IsDelegated(newName, zoneNSes, servers, dnsConfig.Timeout)
+}
 
-	bucketName := hex.EncodeToString(idHash[:])
+// isChangeInSync checks the Route53 change status.
+// Empty ID means the change was done long ago by the previous run.
+func isChangeInSync(r53 *route53.Route53, watchID string) (bool, error) {
+	if watchID == "" {
+		return true, nil
+	}
 
-	_, err := clnS3.CreateBucket(
-		&s3.CreateBucketInput{
-			Bucket: awsSdk.String(bucketName),
-		},
-	)
+	status, err := r53.GetChange(&route53.GetChangeInput{Id: &watchID})
 	if err != nil {
-		return bucketName, err
+		return false, err
 	}
 
-	_, err = clnS3.PutBucketVersioning(
-		&s3.PutBucketVersioningInput{
-			Bucket: awsSdk.String(bucketName),
-			VersioningConfiguration: &s3.VersioningConfiguration{
-				Status: awsSdk.String("Enabled"),
-			},
-		},
-	)
-
-	return bucketName, err
+	return awsSdk.StringValue(status.ChangeInfo.Status) == route53.ChangeStatusInsync, nil
 }
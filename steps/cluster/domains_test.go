@@ -0,0 +1,154 @@
+package cluster
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/powerman/structlog"
+
+	"git.arilot.com/kuberstack/kuberstack-installer/savedstate"
+	"git.arilot.com/kuberstack/kuberstack-installer/steps/cluster/dnsprovider"
+)
+
+const (
+	testDomain = "example.com."
+	testName   = "test.example.com."
+)
+
+var testNSes = []string{"ns-1.awsdns-01.org.", "ns-2.awsdns-02.net."}
+
+func testPrincipal() savedstate.Principal {
+	return savedstate.Principal{ID: "principal", Sess: &savedstate.State{RecWatchID: "previous-change"}}
+}
+
+// failingStep is the step run after the delegation one to get it rolled back
+var failingStep = setupStep{
+	name: "bucket",
+	do: func() (func() error, error) {
+		return nil, errors.New("bucket failed")
+	},
+}
+
+func TestDelegationStep(t *testing.T) {
+	provider := dnsprovider.NewFake(testDomain)
+	domZoneID, _ := provider.ZoneID(testDomain)
+
+	var recWatchID string
+	zoneNSes := testNSes
+	step := delegationStep(provider, domZoneID, testName, &zoneNSes, testPrincipal(), &recWatchID, structlog.New())
+
+	err := runSetup([]setupStep{step}, structlog.New())
+	if err != nil {
+		t.Fatalf("runSetup error: %v", err)
+	}
+
+	if !dnsprovider.SameNameservers(provider.Records[domZoneID][testName], testNSes) {
+		t.Errorf("NS records = %q, want %q", provider.Records[domZoneID][testName], testNSes)
+	}
+
+	inSync, _ := provider.IsChangeInSync(recWatchID)
+	if recWatchID == "" || inSync {
+		t.Errorf("Change %q is not pending", recWatchID)
+	}
+	provider.Sync()
+	inSync, _ = provider.IsChangeInSync(recWatchID)
+	if !inSync {
+		t.Errorf("Change %q is not in sync after Sync", recWatchID)
+	}
+}
+
+func TestDelegationStepRollback(t *testing.T) {
+	provider := dnsprovider.NewFake(testDomain)
+	domZoneID, _ := provider.ZoneID(testDomain)
+
+	var recWatchID string
+	zoneNSes := testNSes
+	step := delegationStep(provider, domZoneID, testName, &zoneNSes, testPrincipal(), &recWatchID, structlog.New())
+
+	err := runSetup([]setupStep{step, failingStep}, structlog.New())
+	if err == nil || err.Error() != "bucket failed" {
+		t.Fatalf("runSetup error = %v, want the bucket step error", err)
+	}
+
+	if records, ok := provider.Records[domZoneID][testName]; ok {
+		t.Errorf("NS records left after rollback: %q", records)
+	}
+}
+
+func TestDelegationStepReused(t *testing.T) {
+	provider := dnsprovider.NewFake(testDomain)
+	domZoneID, _ := provider.ZoneID(testDomain)
+	provider.Records[domZoneID][testName] = testNSes
+
+	var recWatchID string
+	zoneNSes := testNSes
+	step := delegationStep(provider, domZoneID, testName, &zoneNSes, testPrincipal(), &recWatchID, structlog.New())
+
+	err := runSetup([]setupStep{step, failingStep}, structlog.New())
+	if err == nil {
+		t.Fatalf("No runSetup error")
+	}
+
+	// the records created by the previous run are neither recreated nor removed
+	if !dnsprovider.SameNameservers(provider.Records[domZoneID][testName], testNSes) {
+		t.Errorf("NS records = %q, want %q", provider.Records[domZoneID][testName], testNSes)
+	}
+	if recWatchID != "previous-change" {
+		t.Errorf("Watch ID = %q, want the previous one", recWatchID)
+	}
+}
+
+func TestDelegationStepErrors(t *testing.T) {
+	tests := []struct {
+		desc    string
+		prepare func(f *dnsprovider.Fake, zoneID string)
+	}{
+		{
+			"record conflict",
+			func(f *dnsprovider.Fake, zoneID string) {
+				f.Records[zoneID][testName] = []string{"ns.other.com."}
+			},
+		},
+		{
+			"create failed",
+			func(f *dnsprovider.Fake, zoneID string) {
+				f.Errors["CreateDelegation"] = errors.New("throttled")
+			},
+		},
+		{
+			"check failed",
+			func(f *dnsprovider.Fake, zoneID string) {
+				f.Errors["CheckRecordAvailability"] = errors.New("throttled")
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			provider := dnsprovider.NewFake(testDomain)
+			domZoneID, _ := provider.ZoneID(testDomain)
+			tc.prepare(provider, domZoneID)
+
+			undone := false
+			zoneStep := setupStep{
+				name: "hosted zone",
+				do: func() (func() error, error) {
+					return func() error { undone = true; return nil }, nil
+				},
+			}
+
+			var recWatchID string
+			zoneNSes := testNSes
+			step := delegationStep(provider, domZoneID, testName, &zoneNSes, testPrincipal(), &recWatchID, structlog.New())
+
+			err := runSetup([]setupStep{zoneStep, step}, structlog.New())
+			if err == nil {
+				t.Fatalf("No runSetup error")
+			}
+			if !undone {
+				t.Errorf("Hosted zone is not rolled back")
+			}
+		})
+	}
+}
@@ -1,12 +1,19 @@
 package cluster
 
 import (
+	"fmt"
+	"strconv"
+
 	"git.arilot.com/kuberstack/kuberstack-installer/db"
+	"git.arilot.com/kuberstack/kuberstack-installer/predefined"
 	"git.arilot.com/kuberstack/kuberstack-installer/savedstate"
+	"git.arilot.com/kuberstack/kuberstack-installer/steps"
 	"git.arilot.com/kuberstack/kuberstack-installer/steps/install"
+	"git.arilot.com/kuberstack/kuberstack-installer/steps/validation"
 )
 
-// Save saves the cluster params for the future use
+// Save saves the cluster params for the future use.
+// Choosing another cluster type pre-fills the nodes and software with the type defaults.
 func Save(
 	conn db.Connect,
 	domain string,
@@ -14,6 +21,19 @@ func Save(
 	clustType int64,
 	principal savedstate.Principal,
 ) error {
+	if errs := validation.ValidateName(name, domain); len(errs) > 0 {
+		return errs
+	}
+
+	clusterType := predefined.GetType(clustType)
+	if clusterType == nil {
+		return fmt.Errorf("Unknown cluster type: %d", clustType)
+	}
+
+	if principal.Sess.Type != clustType {
+		applyPreset(principal.Sess, clusterType)
+	}
+
 	principal.Sess.Domain = domain
 	principal.Sess.Name = name
 	principal.Sess.Type = clustType
@@ -22,3 +42,21 @@ func Save(
 
 	return conn.SaveState(principal.ID, principal.Sess)
 }
+
+func applyPreset(sess *savedstate.State, clusterType *predefined.ClusterType) {
+	sess.Master = presetGroup(clusterType.Masters, sess.Master.Zones)
+	sess.Nodes = presetGroup(clusterType.Nodes, sess.Nodes.Zones)
+
+	for _, id := range clusterType.Software {
+		product := strconv.Itoa(id)
+		if !steps.StrInSlice(product, sess.Products) {
+			sess.Products = append(sess.Products, product)
+		}
+	}
+}
+
+func presetGroup(preset predefined.GroupPreset, zones []string) savedstate.NodesParams {
+	params := presetParams(preset)
+	params.Zones = zones
+	return params
+}
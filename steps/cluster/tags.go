@@ -0,0 +1,52 @@
+package cluster
+
+import (
+	"sort"
+	"strings"
+
+	awsSdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+// Route53 accepts no more than 10 tags per change
+const maxZoneTagsPerChange = 10
+
+// tagZone sets the tags provided to the hosted zone
+func tagZone(r53 *route53.Route53, zoneID string, tags map[string]string) error {
+	keys := sortedKeys(tags)
+
+	for len(keys) > 0 {
+		chunk := keys
+		if len(chunk) > maxZoneTagsPerChange {
+			chunk = chunk[:maxZoneTagsPerChange]
+		}
+		keys = keys[len(chunk):]
+
+		zoneTags := make([]*route53.Tag, 0, len(chunk))
+		for _, key := range chunk {
+			zoneTags = append(zoneTags, &route53.Tag{Key: awsSdk.String(key), Value: awsSdk.String(tags[key])})
+		}
+
+		_, err := r53.ChangeTagsForResource(
+			&route53.ChangeTagsForResourceInput{
+				ResourceType: awsSdk.String(route53.TagResourceTypeHostedzone),
+				ResourceId:   awsSdk.String(strings.TrimPrefix(zoneID, "/hostedzone/")),
+				AddTags:      zoneTags,
+			},
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func sortedKeys(tags map[string]string) []string {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
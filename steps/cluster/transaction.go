@@ -0,0 +1,50 @@
+package cluster
+
+import (
+	"github.com/powerman/structlog"
+)
+
+// setupStep is a single step of the domain setup.
+// do returns a compensating action for the things it has created,
+// or nil if nothing was created (e.g. resource exists already and is reused).
+type setupStep struct {
+	name string
+	do   func() (undo func() error, err error)
+}
+
+type setupUndo struct {
+	name string
+	undo func() error
+}
+
+// runSetup runs the steps one by one.
+// In the case any step failed all the steps done so far
+// are rolled back in the reverse order and the step error is returned.
+func runSetup(setup []setupStep, logger *structlog.Logger) error {
+	undos := make([]setupUndo, 0, len(setup))
+
+	for _, step := range setup {
+		undo, err := step.do()
+		if err == nil {
+			if undo != nil {
+				undos = append(undos, setupUndo{name: step.name, undo: undo})
+			}
+			continue
+		}
+
+		logger.PrintErr("Domain setup step failed", "step", step.name, "err", err)
+
+		for i := len(undos) - 1; i >= 0; i-- {
+			undoErr := undos[i].undo()
+			if undoErr != nil {
+				logger.PrintErr("Domain setup rollback failed", "step", undos[i].name, "err", undoErr)
+				continue
+			}
+			logger.Info("Domain setup step rolled back", "step", undos[i].name)
+		}
+
+		return err
+	}
+
+	return nil
+}
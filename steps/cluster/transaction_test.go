@@ -0,0 +1,96 @@
+package cluster
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/powerman/structlog"
+)
+
+// recorder keeps the order the steps and their undos are called in
+type recorder struct {
+	calls []string
+}
+
+func (r *recorder) step(name string, fail bool, creates bool, undoErr error) setupStep {
+	return setupStep{
+		name: name,
+		do: func() (func() error, error) {
+			r.calls = append(r.calls, "do "+name)
+			if fail {
+				return nil, fmt.Errorf("%s failed", name)
+			}
+			if !creates {
+				return nil, nil
+			}
+			return func() error {
+				r.calls = append(r.calls, "undo "+name)
+				return undoErr
+			}, nil
+		},
+	}
+}
+
+func TestRunSetup(t *testing.T) {
+	tests := []struct {
+		desc    string
+		failAt  int // -1 for no failure
+		reused  int // step not creating anything, -1 for none
+		undoErr int // step failing to undo, -1 for none
+		calls   []string
+	}{
+		{
+			desc: "success", failAt: -1, reused: -1, undoErr: -1,
+			calls: []string{"do a", "do b", "do c"},
+		},
+		{
+			desc: "first failed", failAt: 0, reused: -1, undoErr: -1,
+			calls: []string{"do a"},
+		},
+		{
+			desc: "second failed", failAt: 1, reused: -1, undoErr: -1,
+			calls: []string{"do a", "do b", "undo a"},
+		},
+		{
+			desc: "last failed", failAt: 2, reused: -1, undoErr: -1,
+			calls: []string{"do a", "do b", "do c", "undo b", "undo a"},
+		},
+		{
+			desc: "reused not undone", failAt: 2, reused: 0, undoErr: -1,
+			calls: []string{"do a", "do b", "do c", "undo b"},
+		},
+		{
+			desc: "undo failure does not stop rollback", failAt: 2, reused: -1, undoErr: 1,
+			calls: []string{"do a", "do b", "do c", "undo b", "undo a"},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			r := &recorder{}
+
+			var setup []setupStep
+			for i, name := range []string{"a", "b", "c"} {
+				var undoErr error
+				if i == tc.undoErr {
+					undoErr = errors.New("undo failed")
+				}
+				setup = append(setup, r.step(name, i == tc.failAt, i != tc.reused, undoErr))
+			}
+
+			err := runSetup(setup, structlog.New())
+			if tc.failAt < 0 && err != nil {
+				t.Errorf("runSetup error: %v", err)
+			}
+			if tc.failAt >= 0 && (err == nil || err.Error() != setup[tc.failAt].name+" failed") {
+				t.Errorf("runSetup error = %v, want the failed step error", err)
+			}
+			if !reflect.DeepEqual(r.calls, tc.calls) {
+				t.Errorf("calls = %q, want %q", r.calls, tc.calls)
+			}
+		})
+	}
+}
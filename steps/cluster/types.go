@@ -2,16 +2,20 @@ package cluster
 
 import (
 	"fmt"
+	"strconv"
 
 	"git.arilot.com/kuberstack/kuberstack-installer/predefined"
 	"git.arilot.com/kuberstack/kuberstack-installer/protocol/gen/models"
+	"git.arilot.com/kuberstack/kuberstack-installer/savedstate"
+	"git.arilot.com/kuberstack/kuberstack-installer/steps/cost"
 )
 
 // PriceFormat is a printf-compatible format for a string price representation
 const PriceFormat string = "%.2f"
 
-// GetTypes returns a copy of cluster types list
-func GetTypes() models.GetClusterTypesOKBodyTypes {
+// GetTypes returns a copy of cluster types list,
+// prices are estimated for the type defaults in the region provided
+func GetTypes(region string) models.GetClusterTypesOKBodyTypes {
 	res := make(models.GetClusterTypesOKBodyTypes, 0, len(predefined.Types))
 
 	for _, t := range predefined.Types {
@@ -22,9 +26,65 @@ func GetTypes() models.GetClusterTypesOKBodyTypes {
 				Name:        t.Name,
 				ShortName:   t.ShortName,
 				Description: t.Description,
-				Price:       fmt.Sprintf(PriceFormat, t.Price),
+				Price:       fmt.Sprintf(PriceFormat, typePrice(t, region)),
+
+				Masters:          groupPresetModel(t.Masters),
+				Nodes:            groupPresetModel(t.Nodes),
+				InstanceFamilies: t.InstanceFamilies,
+				MultiZone:        t.MultiZone,
+				Software:         productIDs(t.Software),
 			},
 		)
 	}
 	return res
 }
+
+// typePrice returns a monthly price of the type defaults,
+// the predefined price is used if the region is not priced
+func typePrice(t predefined.ClusterType, region string) float64 {
+	est, err := cost.Calculate(
+		cost.Cluster{
+			Region: region,
+			Groups: []cost.Group{
+				{Name: "master", Master: true, Params: presetParams(t.Masters)},
+				{Name: "nodes", Params: presetParams(t.Nodes)},
+			},
+			LoadBalancers: 1,
+			HostedZones:   1,
+		},
+	)
+	if err != nil {
+		return t.Price
+	}
+	return est.Total
+}
+
+func presetParams(preset predefined.GroupPreset) savedstate.NodesParams {
+	return savedstate.NodesParams{
+		Type:        preset.InstanceType,
+		Quantity:    preset.Count,
+		MaxSize:     preset.MaxSize,
+		StorageSize: preset.StorageSize,
+		StorageType: preset.StorageType,
+	}
+}
+
+func groupPresetModel(preset predefined.GroupPreset) *models.GroupPreset {
+	return &models.GroupPreset{
+		Count:        preset.Count,
+		Min:          preset.Min,
+		Max:          preset.Max,
+		MaxSize:      preset.MaxSize,
+		InstanceType: preset.InstanceType,
+		StorageSize:  preset.StorageSize,
+		StorageType:  preset.StorageType,
+	}
+}
+
+func productIDs(ids []int) []string {
+	res := make([]string, 0, len(ids))
+	for _, id := range ids {
+		res = append(res, strconv.Itoa(id))
+	}
+	return res
+}
@@ -0,0 +1,270 @@
+package cost
+
+import (
+	"fmt"
+	"strings"
+
+	"git.arilot.com/kuberstack/kuberstack-installer/predefined"
+	"git.arilot.com/kuberstack/kuberstack-installer/savedstate"
+)
+
+// UnitPriceFormat is a printf-compatible format for a unit price,
+// hourly based prices need more precision than the totals
+const UnitPriceFormat string = "%.4f"
+
+// Defaults kops applies to the resources not configured explicitly
+const (
+	defaultVolumeType = "gp2"
+	// kops creates two etcd volumes (main and events) per master
+	etcdVolumesPerMaster = 2
+	etcdVolumeSize       = 20
+	bastionVolumeSize    = 32
+	// gp3 storage price includes the baseline performance
+	gp3Type               = "gp3"
+	gp3BaselineIOPS       = 3000
+	gp3BaselineThroughput = 125
+)
+
+// Group is a group of instances to be priced
+type Group struct {
+	Name   string
+	Master bool
+	Params savedstate.NodesParams
+}
+
+// Cluster is a set of resources to be priced
+type Cluster struct {
+	Region        string
+	Groups        []Group
+	LoadBalancers int64
+	NATGateways   int64
+	HostedZones   int64
+}
+
+// Item is a single line of the estimate, prices are monthly
+type Item struct {
+	Name      string
+	Quantity  float64
+	Unit      string
+	UnitPrice float64
+	Monthly   float64
+}
+
+// Estimate is an itemised monthly cost estimate
+type Estimate struct {
+	Region   string
+	Currency string
+	Items    []Item
+	Total    float64
+}
+
+// FromState returns the resources kops is going to create for the session config
+func FromState(sess *savedstate.State) Cluster {
+	groups := []Group{
+		{Name: "master", Master: true, Params: sess.Master},
+		{Name: "nodes", Params: sess.Nodes},
+	}
+	for _, group := range sess.Groups {
+		groups = append(groups, Group{Name: group.Name, Params: group.NodesParams})
+	}
+
+	cluster := Cluster{
+		Region: sess.Region,
+		Groups: groups,
+		// kops is always asked for the API load balancer,
+		// NAT gateways are set up with the private topology only
+		LoadBalancers: 1,
+		NATGateways:   0,
+		HostedZones:   1,
+	}
+
+	if sess.Bastion != nil {
+		cluster.Groups = append(
+			cluster.Groups,
+			Group{
+				Name:   "bastion",
+				Params: savedstate.NodesParams{Type: sess.Bastion.Type, Quantity: 1, StorageSize: bastionVolumeSize},
+			},
+		)
+		// the bastion load balancer, a NAT gateway per zone
+		cluster.LoadBalancers++
+		cluster.NATGateways = int64(len(clusterZones(sess)))
+	}
+
+	return cluster
+}
+
+// clusterZones returns the zones kops creates the subnets in
+func clusterZones(sess *savedstate.State) map[string]bool {
+	zones := make(map[string]bool)
+	for _, zone := range append(append([]string{}, sess.Master.Zones...), sess.Nodes.Zones...) {
+		zones[zone] = true
+	}
+	return zones
+}
+
+// EstimateState returns the monthly cost estimate for the cluster configured in the session
+func EstimateState(sess *savedstate.State) (*Estimate, error) {
+	return Calculate(FromState(sess))
+}
+
+// Calculate returns the monthly cost estimate for the resources provided,
+// groups with no instance type or quantity set are skipped
+func Calculate(cluster Cluster) (*Estimate, error) {
+	prices := predefined.GetRegionPrices(cluster.Region)
+	if prices == nil {
+		return nil, fmt.Errorf("No prices known for region %q", cluster.Region)
+	}
+
+	hours := predefined.Prices.HoursPerMonth
+	est := &Estimate{
+		Region:   cluster.Region,
+		Currency: predefined.Prices.Currency,
+	}
+
+	for _, group := range cluster.Groups {
+		params := group.Params
+		if params.Type == "" || params.Quantity <= 0 {
+			continue
+		}
+
+		hourly, ok := prices.Instances[params.Type]
+		if !ok {
+			return nil, fmt.Errorf("No price known for instance type %q in region %q", params.Type, cluster.Region)
+		}
+		onDemand, spot := params.Quantity, int64(0)
+		if params.Spot != nil {
+			onDemand = params.Spot.OnDemandBase
+			if onDemand > params.Quantity {
+				onDemand = params.Quantity
+			}
+			spot = params.Quantity - onDemand
+		}
+
+		est.add(
+			fmt.Sprintf("%s instances (%s)", group.Name, params.Type),
+			float64(onDemand),
+			"instance",
+			hourly*hours,
+		)
+		if spot > 0 {
+			types := spotTypes(params)
+			spotHourly, err := spotPrice(prices, cluster.Region, types, params.Spot.MaxPrice)
+			if err != nil {
+				return nil, err
+			}
+			est.add(
+				fmt.Sprintf("%s spot instances (%s)", group.Name, strings.Join(types, ", ")),
+				float64(spot),
+				"instance",
+				spotHourly*hours,
+			)
+		}
+
+		err := est.addVolume(prices, cluster.Region, group.Name+" volumes", params.Quantity, params.RootVolume())
+		if err != nil {
+			return nil, err
+		}
+		for _, volume := range params.Volumes {
+			err = est.addVolume(prices, cluster.Region, group.Name+" data volumes "+volume.Device, params.Quantity, volume)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if group.Master {
+			est.add(
+				fmt.Sprintf("%s etcd volumes (%s)", group.Name, defaultVolumeType),
+				float64(params.Quantity*etcdVolumesPerMaster*etcdVolumeSize),
+				"GB",
+				prices.Volumes[defaultVolumeType].GBMonth,
+			)
+		}
+	}
+
+	est.add("load balancers", float64(cluster.LoadBalancers), "load balancer", prices.LoadBalancer*hours)
+	est.add("NAT gateways", float64(cluster.NATGateways), "gateway", prices.NATGateway*hours)
+	est.add("hosted zones", float64(cluster.HostedZones), "zone", predefined.Prices.HostedZone)
+
+	return est, nil
+}
+
+// addVolume adds the storage and the provisioned performance of count volumes
+func (est *Estimate) addVolume(
+	prices *predefined.RegionPrices,
+	region string,
+	name string,
+	count int64,
+	volume savedstate.VolumeParams,
+) error {
+	volumeType := volume.Type
+	if volumeType == "" {
+		volumeType = defaultVolumeType
+	}
+	price, ok := prices.Volumes[volumeType]
+	if !ok {
+		return fmt.Errorf("No price known for volume type %q in region %q", volumeType, region)
+	}
+
+	iops, throughput := volume.IOPS, volume.Throughput
+	if volumeType == gp3Type {
+		iops -= gp3BaselineIOPS
+		throughput -= gp3BaselineThroughput
+	}
+
+	est.add(fmt.Sprintf("%s (%s)", name, volumeType), float64(count*volume.Size), "GB", price.GBMonth)
+	est.add(fmt.Sprintf("%s IOPS (%s)", name, volumeType), float64(count*iops), "IOPS", price.IOPSMonth)
+	est.add(fmt.Sprintf("%s throughput (%s)", name, volumeType), float64(count*throughput), "MiB/s", price.ThroughputMonth)
+
+	return nil
+}
+
+// spotTypes returns the group instance type followed by the interchangeable ones
+func spotTypes(params savedstate.NodesParams) []string {
+	types := []string{params.Type}
+	seen := map[string]bool{params.Type: true}
+	for _, instanceType := range params.Spot.Types {
+		if !seen[instanceType] {
+			seen[instanceType] = true
+			types = append(types, instanceType)
+		}
+	}
+	return types
+}
+
+// spotPrice returns the spot price averaged over the instance types
+// the spot instances are picked from, every type price is limited by the max price set.
+// The on-demand price is used for the type the spot one is not known for.
+func spotPrice(prices *predefined.RegionPrices, region string, types []string, maxPrice float64) (float64, error) {
+	var sum float64
+	for _, instanceType := range types {
+		hourly, ok := prices.SpotInstances[instanceType]
+		if !ok {
+			hourly, ok = prices.Instances[instanceType]
+		}
+		if !ok {
+			return 0, fmt.Errorf("No price known for instance type %q in region %q", instanceType, region)
+		}
+		if maxPrice > 0 && maxPrice < hourly {
+			hourly = maxPrice
+		}
+		sum += hourly
+	}
+	return sum / float64(len(types)), nil
+}
+
+func (est *Estimate) add(name string, quantity float64, unit string, unitPrice float64) {
+	if quantity <= 0 {
+		return
+	}
+
+	item := Item{
+		Name:      name,
+		Quantity:  quantity,
+		Unit:      unit,
+		UnitPrice: unitPrice,
+		Monthly:   quantity * unitPrice,
+	}
+	est.Items = append(est.Items, item)
+	est.Total += item.Monthly
+}
@@ -0,0 +1,141 @@
+package cost
+
+import (
+	"math"
+	"testing"
+
+	"git.arilot.com/kuberstack/kuberstack-installer/predefined"
+	"git.arilot.com/kuberstack/kuberstack-installer/savedstate"
+)
+
+const testRegion = "test-1"
+
+var testPrices = predefined.PriceList{
+	Currency:      "USD",
+	HoursPerMonth: 100,
+	HostedZone:    0.5,
+	Regions: map[string]predefined.RegionPrices{
+		testRegion: {
+			Instances:     map[string]float64{"m5.large": 0.1, "c5.large": 0.08, "t3.micro": 0.01},
+			SpotInstances: map[string]float64{"m5.large": 0.04},
+			Volumes:       map[string]predefined.VolumePrice{"gp2": {GBMonth: 0.1}},
+			LoadBalancer:  0.02,
+			NATGateway:    0.05,
+		},
+	},
+}
+
+// testState returns a public cluster of a master and two nodes,
+// it is 39.5 a month: 10 + 1 + 4 (etcd) for the master, 20 + 2 for the nodes,
+// 2 for the API load balancer and 0.5 for the hosted zone
+func testState() *savedstate.State {
+	return &savedstate.State{
+		Region: testRegion,
+		Master: savedstate.NodesParams{Type: "m5.large", Quantity: 1, StorageSize: 10, Zones: []string{"a"}},
+		Nodes:  savedstate.NodesParams{Type: "m5.large", Quantity: 2, StorageSize: 10, Zones: []string{"a"}},
+	}
+}
+
+func TestEstimateState(t *testing.T) {
+	saved := predefined.Prices
+	predefined.Prices = testPrices
+	defer func() { predefined.Prices = saved }()
+
+	tests := []struct {
+		name    string
+		modify  func(*savedstate.State)
+		lbs     int64
+		nats    int64
+		total   float64
+		wantErr bool
+	}{
+		{
+			name:   "on-demand",
+			modify: func(*savedstate.State) {},
+			lbs:    1,
+			total:  39.5,
+		},
+		{
+			name: "spot with on-demand base",
+			modify: func(s *savedstate.State) {
+				// 1 on-demand for 10, 3 spot for 6 each averaged over m5.large and c5.large
+				s.Nodes.Quantity = 4
+				s.Nodes.Spot = &savedstate.SpotParams{OnDemandBase: 1, Types: []string{"c5.large", "m5.large"}}
+			},
+			lbs:   1,
+			total: 39.5 - 22 + 10 + 18 + 4,
+		},
+		{
+			name: "on-demand base above quantity",
+			modify: func(s *savedstate.State) {
+				s.Nodes.Spot = &savedstate.SpotParams{OnDemandBase: 5}
+			},
+			lbs:   1,
+			total: 39.5,
+		},
+		{
+			name: "spot max price cap",
+			modify: func(s *savedstate.State) {
+				// m5.large spot for 0.04 and c5.large capped to 0.05
+				s.Nodes.Spot = &savedstate.SpotParams{MaxPrice: 0.05, Types: []string{"c5.large"}}
+			},
+			lbs:   1,
+			total: 39.5 - 20 + 9,
+		},
+		{
+			name: "spot type not priced",
+			modify: func(s *savedstate.State) {
+				s.Nodes.Spot = &savedstate.SpotParams{Types: []string{"x1.large"}}
+			},
+			wantErr: true,
+		},
+		{
+			name: "bastion",
+			modify: func(s *savedstate.State) {
+				// the bastion is 1 + 3.2 for the volume, one more load balancer
+				// and a NAT gateway for the only zone
+				s.Bastion = &savedstate.BastionParams{Type: "t3.micro"}
+			},
+			lbs:   2,
+			nats:  1,
+			total: 39.5 + 4.2 + 2 + 5,
+		},
+		{
+			name: "NAT gateways per zone",
+			modify: func(s *savedstate.State) {
+				s.Bastion = &savedstate.BastionParams{Type: "t3.micro"}
+				s.Master.Zones = []string{"a", "b", "c"}
+				s.Nodes.Zones = []string{"a", "b"}
+			},
+			lbs:   2,
+			nats:  3,
+			total: 39.5 + 4.2 + 2 + 15,
+		},
+	}
+
+	for _, tc := range tests {
+		sess := testState()
+		tc.modify(sess)
+
+		est, err := EstimateState(sess)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("%s: no error", tc.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: error: %v", tc.name, err)
+			continue
+		}
+
+		cluster := FromState(sess)
+		if cluster.LoadBalancers != tc.lbs || cluster.NATGateways != tc.nats {
+			t.Errorf("%s: load balancers %d, NAT gateways %d, want %d, %d",
+				tc.name, cluster.LoadBalancers, cluster.NATGateways, tc.lbs, tc.nats)
+		}
+		if math.Abs(est.Total-tc.total) > 1e-9 {
+			t.Errorf("%s: total %v, want %v, items %+v", tc.name, est.Total, tc.total, est.Items)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package steps
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Field error codes to be handled by the client
+const (
+	CodeRequired    = "required"
+	CodeInvalid     = "invalid"
+	CodeOutOfRange  = "out_of_range"
+	CodeUnsupported = "unsupported"
+	CodeConflict    = "conflict"
+)
+
+// FieldError is a validation error bound to the particular field of the cluster spec
+type FieldError struct {
+	Field   string
+	Code    string
+	Message string
+}
+
+// FieldErrors is a list of validation errors
+type FieldErrors []FieldError
+
+// Add appends a new error to the list
+func (errs *FieldErrors) Add(field string, code string, format string, args ...interface{}) {
+	*errs = append(*errs, FieldError{Field: field, Code: code, Message: fmt.Sprintf(format, args...)})
+}
+
+// Error implements error interface
+func (errs FieldErrors) Error() string {
+	msgs := make([]string, 0, len(errs))
+	for _, e := range errs {
+		msgs = append(msgs, e.Field+": "+e.Message)
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Err returns nil for an empty list and the list itself otherwise
+func (errs FieldErrors) Err() error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
@@ -0,0 +1,202 @@
+package install
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/powerman/structlog"
+	yaml "gopkg.in/yaml.v2"
+
+	"git.arilot.com/kuberstack/kuberstack-installer/predefined"
+	"git.arilot.com/kuberstack/kuberstack-installer/savedstate"
+	"git.arilot.com/kuberstack/kuberstack-installer/steps"
+	"git.arilot.com/kuberstack/kuberstack-installer/steps/software"
+
+	awsSdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+const (
+	autoscalerName  = "cluster-autoscaler"
+	autoscalerAddon = "cluster-autoscaler.addons.k8s.io"
+
+	// tags cluster autoscaler discovers the autoscaling groups by
+	autoscalerEnabledTag = "k8s.io/cluster-autoscaler/enabled"
+	autoscalerClusterTag = "k8s.io/cluster-autoscaler/"
+)
+
+// autoscalerPolicy is the IAM policy statements kops adds to the masters role,
+// the autoscaler runs on the masters
+const autoscalerPolicy = `[
+  {
+    "Effect": "Allow",
+    "Action": [
+      "autoscaling:DescribeAutoScalingGroups",
+      "autoscaling:DescribeAutoScalingInstances",
+      "autoscaling:DescribeLaunchConfigurations",
+      "autoscaling:DescribeTags",
+      "autoscaling:SetDesiredCapacity",
+      "autoscaling:TerminateInstanceInAutoScalingGroup",
+      "ec2:DescribeInstanceTypes",
+      "ec2:DescribeLaunchTemplateVersions"
+    ],
+    "Resource": "*"
+  }
+]`
+
+type addonChannel struct {
+	Kind     string           `yaml:"kind"`
+	Metadata manifestMetadata `yaml:"metadata"`
+	Spec     struct {
+		Addons []addonSpec `yaml:"addons"`
+	} `yaml:"spec"`
+}
+
+type addonSpec struct {
+	Name     string            `yaml:"name"`
+	Version  string            `yaml:"version"`
+	Selector map[string]string `yaml:"selector"`
+	Manifest string            `yaml:"manifest"`
+}
+
+// autoscalerVersion returns the autoscaler image version matching the cluster Kubernetes version,
+// empty string is returned for the version not supported
+func autoscalerVersion(sess *savedstate.State) string {
+	version := predefined.GetKubernetesVersion(sess.KubernetesVersion)
+	if version == nil {
+		return ""
+	}
+	return version.Autoscaler
+}
+
+func autoscalerEnabled(sess *savedstate.State) bool {
+	return software.Selected(sess.Products, software.ClusterAutoscalerID)
+}
+
+// autoscalerLabels returns the cloud labels the autoscaler discovers the group by,
+// nil is returned for the fixed size groups
+func autoscalerLabels(clusterName string, sess *savedstate.State, group savedstate.NodesParams) map[string]string {
+	if !autoscalerEnabled(sess) || group.MaxSize <= group.Quantity {
+		return nil
+	}
+
+	return map[string]string{
+		autoscalerEnabledTag:               "",
+		autoscalerClusterTag + clusterName: "",
+	}
+}
+
+// setupAutoscaler uploads the autoscaler addon to the state store
+// and adds it to the cluster spec along with the IAM policy it needs.
+// The changes are applied by the following cluster update.
+func setupAutoscaler(ctx context.Context, r kopsRunner) error {
+	addonURL, err := uploadAutoscalerAddon(r.sess.ClusterName(), r.sess, r.logger)
+	if err != nil {
+		return err
+	}
+
+	return patchCluster(
+		ctx,
+		r,
+		func(manifest yaml.MapSlice) (yaml.MapSlice, error) {
+			policies, _ := getSpecField(manifest, "additionalPolicies").(yaml.MapSlice)
+			manifest, err := setSpecField(manifest, "additionalPolicies", setField(policies, "master", autoscalerPolicy))
+			if err != nil {
+				return nil, err
+			}
+
+			addons, _ := getSpecField(manifest, "addons").([]interface{})
+			for _, addon := range addons {
+				// added by the previous attempt already
+				if addon, ok := addon.(yaml.MapSlice); ok && getField(addon, "manifest") == addonURL {
+					return manifest, nil
+				}
+			}
+			addons = append(addons, yaml.MapSlice{{Key: "manifest", Value: addonURL}})
+			return setSpecField(manifest, "addons", addons)
+		},
+	)
+}
+
+// uploadAutoscalerAddon puts the addon channel and manifest next to the cluster state
+// and returns the channel URL
+func uploadAutoscalerAddon(
+	clusterName string,
+	sess *savedstate.State,
+	logger *structlog.Logger,
+) (string, error) {
+	version := autoscalerVersion(sess)
+	if version == "" {
+		logger.PrintErr("No autoscaler version for the Kubernetes one", "version", sess.KubernetesVersion)
+		return "", fmt.Errorf("Internal server error")
+	}
+
+	var manifest bytes.Buffer
+	err := predefined.ClusterAutoscaler.Execute(
+		&manifest,
+		struct {
+			ClusterName string
+			Region      string
+			Version     string
+		}{
+			ClusterName: clusterName,
+			Region:      sess.Region,
+			Version:     version,
+		},
+	)
+	if err != nil {
+		logger.PrintErr("Autoscaler manifest render error", "err", err)
+		return "", fmt.Errorf("Internal server error")
+	}
+
+	manifestName := strings.TrimPrefix(version, "v") + ".yaml"
+
+	channel := addonChannel{
+		Kind:     "Addons",
+		Metadata: manifestMetadata{Name: autoscalerName},
+	}
+	channel.Spec.Addons = []addonSpec{
+		{
+			Name:     autoscalerAddon,
+			Version:  strings.TrimPrefix(version, "v"),
+			Selector: map[string]string{"k8s-addon": autoscalerAddon},
+			Manifest: manifestName,
+		},
+	}
+
+	channelContent, err := yaml.Marshal(&channel)
+	if err != nil {
+		logger.PrintErr("Autoscaler channel marshal error", "err", err)
+		return "", fmt.Errorf("Internal server error")
+	}
+
+	awsSess, err := steps.AwsSession(sess.AccessKey, sess.SecretKey, sess.Region)
+	if err != nil {
+		return "", err
+	}
+	clnS3 := s3.New(awsSess)
+
+	dir := path.Join(sess.BucketPrefix, clusterName, "addons", autoscalerName)
+
+	for name, content := range map[string][]byte{
+		manifestName: manifest.Bytes(),
+		"addon.yaml": channelContent,
+	} {
+		_, err = clnS3.PutObject(
+			&s3.PutObjectInput{
+				Bucket: awsSdk.String(sess.Bucket),
+				Key:    awsSdk.String(strings.TrimPrefix(path.Join(dir, name), "/")),
+				Body:   bytes.NewReader(content),
+			},
+		)
+		if err != nil {
+			logger.PrintErr("Autoscaler addon upload error", "err", err, "bucket", sess.Bucket, "dir", dir)
+			return "", fmt.Errorf("Internal server error")
+		}
+	}
+
+	return "s3://" + path.Join(sess.Bucket, strings.TrimPrefix(dir, "/"), "addon.yaml"), nil
+}
@@ -0,0 +1,34 @@
+package install
+
+import (
+	"context"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"git.arilot.com/kuberstack/kuberstack-installer/steps/network"
+)
+
+// setupBastion sets the bastion instance type and DNS name kops create has no flags for.
+// The changes are applied by the following cluster update.
+func setupBastion(ctx context.Context, r kopsRunner) error {
+	sess := r.sess
+	if sess.Bastion == nil {
+		return nil
+	}
+
+	err := patchGroup(ctx, r, network.BastionGroup, yaml.MapSlice{{Key: "machineType", Value: sess.Bastion.Type}})
+	if err != nil {
+		return err
+	}
+
+	return patchCluster(
+		ctx,
+		r,
+		func(manifest yaml.MapSlice) (yaml.MapSlice, error) {
+			topology, _ := getSpecField(manifest, "topology").(yaml.MapSlice)
+			bastion, _ := getField(topology, "bastion").(yaml.MapSlice)
+			bastion = setField(bastion, "bastionPublicName", network.BastionName(sess))
+			return setSpecField(manifest, "topology", setField(topology, "bastion", bastion))
+		},
+	)
+}
@@ -0,0 +1,210 @@
+package install
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/powerman/structlog"
+
+	"git.arilot.com/kuberstack/kuberstack-installer/db"
+	"git.arilot.com/kuberstack/kuberstack-installer/kops"
+	"git.arilot.com/kuberstack/kuberstack-installer/savedstate"
+	"git.arilot.com/kuberstack/kuberstack-installer/steps"
+	"git.arilot.com/kuberstack/kuberstack-installer/steps/validation"
+	"git.arilot.com/kuberstack/kuberstack-installer/steps/versions"
+)
+
+// kops targets the cluster is exported to
+const (
+	TargetTerraform      = "terraform"
+	TargetCloudFormation = "cloudformation"
+)
+
+// the code is written to the subdir of the kops home dir
+const exportDir = "out"
+
+// Export runs kops with the Terraform or CloudFormation target instead of applying the changes
+// and returns the archive name and the tar.gz archive of the code generated.
+// Only the state store is changed, the cluster is created by applying the code.
+// The first export creates the cluster spec in the state store, the following ones regenerate the code of it.
+// kops is run bound by the runner timeout only, so the client gone does not stop the spec changes halfway.
+func Export(
+	conn db.Connect,
+	principal savedstate.Principal,
+	target string,
+	executor kops.Executor,
+	tmpDir string,
+	timeout time.Duration,
+	logger *structlog.Logger,
+) (string, []byte, error) {
+	logger = logger.New("id", principal.ID).AppendPrefixKeys("id")
+
+	var errs steps.FieldErrors
+	switch {
+	case target != TargetTerraform && target != TargetCloudFormation:
+		errs.Add("target", steps.CodeUnsupported, "Export target is not supported: %q", target)
+	case isInstalled(principal.Sess):
+		errs.Add("target", steps.CodeConflict, "Cluster is installed already")
+	}
+	if len(errs) > 0 {
+		return "", nil, errs
+	}
+
+	sess := principal.Sess
+	clusterName := sess.ClusterName()
+	homeDir := filepath.Join(tmpDir, principal.ID)
+	outDir := filepath.Join(homeDir, exportDir)
+
+	err := os.RemoveAll(outDir)
+	if err != nil {
+		logger.PrintErr("Export dir cleanup error", "err", err)
+		return "", nil, fmt.Errorf("Internal server error")
+	}
+
+	err = os.MkdirAll(homeDir, 0700)
+	if err != nil {
+		logger.PrintErr("Kops home dir creation error", "err", err)
+		return "", nil, fmt.Errorf("Internal server error")
+	}
+
+	r := newKopsRunner(conn, principal, "export", executor, tmpDir, timeout, logger)
+	ctx := context.Background()
+
+	if sess.ExportTarget == "" {
+		errs = validation.Validate(sess)
+		if len(errs) > 0 {
+			return "", nil, errs
+		}
+
+		versions.Resolve(sess)
+
+		err = saveSSHkey(sess.SSHPubKey, filepath.Join(homeDir, sshKeyFile), logger)
+		if err != nil {
+			return "", nil, err
+		}
+
+		config := createConfig(homeDir, sess)
+		config.Target, config.Out = target, outDir
+
+		err = r.run(ctx, config)
+		if err != nil {
+			return "", nil, err
+		}
+
+		// the spec is in the state store now, the retries must not create it again
+		sess.ExportTarget = target
+		err = conn.SaveState(principal.ID, sess)
+		if err != nil {
+			return "", nil, err
+		}
+	}
+
+	// the spec steps are safe to be repeated until they all succeed
+	if !sess.ExportSpecReady {
+		err = setupBastion(ctx, r)
+		if err != nil {
+			return "", nil, err
+		}
+
+		err = createGroups(ctx, r)
+		if err != nil {
+			return "", nil, err
+		}
+
+		if autoscalerEnabled(sess) {
+			err = setupAutoscaler(ctx, r)
+			if err != nil {
+				return "", nil, err
+			}
+		}
+
+		sess.ExportSpecReady = true
+		err = conn.SaveState(principal.ID, sess)
+		if err != nil {
+			return "", nil, err
+		}
+	}
+
+	err = r.run(ctx, kops.Config{KopsUpdate: true, Target: target, Out: outDir})
+	if err != nil {
+		return "", nil, err
+	}
+
+	sess.ExportTarget = target
+	err = conn.SaveState(principal.ID, sess)
+	if err != nil {
+		return "", nil, err
+	}
+
+	name := clusterName + "-" + target
+	archive, err := archiveDir(outDir, name)
+	if err != nil {
+		logger.PrintErr("Export archive error", "err", err, "dir", outDir)
+		return "", nil, fmt.Errorf("Internal server error")
+	}
+
+	return name + ".tar.gz", archive, nil
+}
+
+// archiveDir returns the tar.gz archive of the dir files put under the prefix dir
+func archiveDir(dir string, prefix string) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	err := filepath.Walk(
+		dir,
+		func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return err
+			}
+
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+
+			content, err := ioutil.ReadFile(path) // #nosec
+			if err != nil {
+				return err
+			}
+
+			err = tw.WriteHeader(
+				&tar.Header{
+					Name:    filepath.ToSlash(filepath.Join(prefix, rel)),
+					Mode:    0644,
+					Size:    int64(len(content)),
+					ModTime: info.ModTime(),
+				},
+			)
+			if err != nil {
+				return err
+			}
+
+			_, err = tw.Write(content)
+			return err
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tw.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	err = gz.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,217 @@
+package install
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/powerman/structlog"
+
+	"git.arilot.com/kuberstack/kuberstack-installer/db"
+	"git.arilot.com/kuberstack/kuberstack-installer/kops"
+	"git.arilot.com/kuberstack/kuberstack-installer/savedstate"
+	"git.arilot.com/kuberstack/kuberstack-installer/steps"
+	"git.arilot.com/kuberstack/kuberstack-installer/steps/nodes"
+)
+
+// kops name of the group created by the --node-* flags
+const defaultNodesGroup = "nodes"
+
+// SaveGroup adds a new additional instance group or replaces the one of the same name.
+// The groups of an installed cluster are applied by kops right away,
+// the running instances are replaced on the next rolling update only.
+// kops is bound by the timeout only, so it is not stopped halfway by the client gone.
+func SaveGroup(
+	conn db.Connect,
+	principal savedstate.Principal,
+	group savedstate.InstanceGroup,
+	imagesConfig nodes.ImagesConfig,
+	executor kops.Executor,
+	tmpDir string,
+	timeout time.Duration,
+	logger *structlog.Logger,
+) error {
+	logger = logger.New("id", principal.ID).AppendPrefixKeys("id")
+
+	errs := nodes.ValidateInstanceGroup(group, principal.Sess.Region)
+	if len(errs) > 0 {
+		return errs
+	}
+
+	offerings, err := nodes.GetOfferings(principal.Sess.Region, principal)
+	if err != nil {
+		return err
+	}
+
+	errs = nodes.ValidateOfferings("groups."+group.Name, group.NodesParams, offerings)
+	if len(errs) > 0 {
+		return errs
+	}
+
+	sess, err := steps.AwsSession(principal.Sess.AccessKey, principal.Sess.SecretKey, principal.Sess.Region)
+	if err != nil {
+		return err
+	}
+
+	err = nodes.ResolveImage(sess, "groups."+group.Name, &group.NodesParams, imagesConfig)
+	if err != nil {
+		return err
+	}
+
+	existing := principal.Sess.GetGroup(group.Name)
+
+	if isInstalled(principal.Sess) {
+		action := kops.Config{KopsCreateGroup: true}
+		if existing != nil {
+			action = kops.Config{KopsReplaceGroup: true}
+		}
+
+		r := newKopsRunner(conn, principal, "group", executor, tmpDir, timeout, logger)
+		err = applyGroup(context.Background(), r, group, action)
+		if err != nil {
+			return err
+		}
+	}
+
+	if existing != nil {
+		*existing = group
+	} else {
+		principal.Sess.Groups = append(principal.Sess.Groups, group)
+	}
+
+	return conn.SaveState(principal.ID, principal.Sess)
+}
+
+// DeleteGroup removes an additional instance group,
+// kops deletes it right away if the cluster is installed bound by the timeout only
+func DeleteGroup(
+	conn db.Connect,
+	principal savedstate.Principal,
+	name string,
+	executor kops.Executor,
+	tmpDir string,
+	timeout time.Duration,
+	logger *structlog.Logger,
+) error {
+	logger = logger.New("id", principal.ID).AppendPrefixKeys("id")
+
+	if principal.Sess.GetGroup(name) == nil {
+		return fmt.Errorf("Unknown instance group: %q", name)
+	}
+
+	if isInstalled(principal.Sess) {
+		r := newKopsRunner(conn, principal, "group", executor, tmpDir, timeout, logger)
+		err := r.run(context.Background(), kops.Config{KopsDeleteGroup: true, GroupName: name})
+		if err != nil {
+			return err
+		}
+	}
+
+	groups := principal.Sess.Groups[:0]
+	for _, group := range principal.Sess.Groups {
+		if group.Name != name {
+			groups = append(groups, group)
+		}
+	}
+	principal.Sess.Groups = groups
+
+	return conn.SaveState(principal.ID, principal.Sess)
+}
+
+// applyGroup creates or replaces the instance group and updates the cluster
+func applyGroup(ctx context.Context, r kopsRunner, group savedstate.InstanceGroup, action kops.Config) error {
+	err := os.MkdirAll(r.homeDir, 0700)
+	if err != nil {
+		r.logger.PrintErr("Kops home dir creation error", "err", err)
+		return fmt.Errorf("Internal server error")
+	}
+
+	err = kopsGroup(ctx, r, action, group)
+	if err != nil {
+		return err
+	}
+
+	return r.run(ctx, kops.Config{KopsUpdate: true})
+}
+
+// createGroups creates all the additional instance groups of the new cluster
+// and patches the default ones, they are applied by the following cluster update.
+// kops create has no flags for the spot options, the max size, the storage options and the nodes image,
+// so the default nodes group is replaced if any of them requested
+// or the nodes image differs from the masters one.
+// No image in the manifest makes kops set its default one.
+func createGroups(ctx context.Context, r kopsRunner) error {
+	sess := r.sess
+
+	err := patchMasterGroups(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	if sess.Nodes.Spot != nil ||
+		sess.Nodes.MaxSize > sess.Nodes.Quantity ||
+		sess.Nodes.HasStorageOptions() ||
+		nodes.GroupImage(sess.Nodes) != nodes.GroupImage(sess.Master) {
+		nodesGroup := savedstate.InstanceGroup{Name: defaultNodesGroup, NodesParams: sess.Nodes}
+
+		err = kopsGroup(ctx, r, kops.Config{KopsReplaceGroup: true}, nodesGroup)
+		if err != nil {
+			return err
+		}
+	}
+
+	// forced replace creates the group missing and keeps the retried export from failing on the existing one
+	for _, group := range sess.Groups {
+		err = kopsGroup(ctx, r, kops.Config{KopsReplaceGroup: true, Force: true}, group)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// kopsGroup creates or replaces the instance group with its manifest, the action config sets the kops command
+func kopsGroup(ctx context.Context, r kopsRunner, action kops.Config, group savedstate.InstanceGroup) error {
+	clusterName := r.sess.ClusterName()
+
+	fileName, err := saveGroupManifest(r.homeDir, clusterName, group, autoscalerLabels(clusterName, r.sess, group.NodesParams), r.logger)
+	if err != nil {
+		return err
+	}
+
+	action.Filename = fileName
+
+	return r.run(ctx, action)
+}
+
+func saveGroupManifest(
+	homeDir string,
+	clusterName string,
+	group savedstate.InstanceGroup,
+	cloudLabels map[string]string,
+	logger *structlog.Logger,
+) (string, error) {
+	manifest, err := groupManifest(clusterName, group, cloudLabels)
+	if err != nil {
+		logger.PrintErr("Instance group manifest error", "err", err, "group", group.Name)
+		return "", fmt.Errorf("Internal server error")
+	}
+
+	fileName := filepath.Join(homeDir, "ig-"+group.Name+".yaml")
+	err = ioutil.WriteFile(fileName, manifest, 0600)
+	if err != nil {
+		logger.PrintErr("Instance group manifest write error", "err", err, "file", fileName)
+		return "", fmt.Errorf("Internal server error")
+	}
+
+	return fileName, nil
+}
+
+// isInstalled checks if kops has already created the cluster
+func isInstalled(sess *savedstate.State) bool {
+	return len(sess.Kubecfg) > 0
+}
@@ -1,11 +1,11 @@
 package install
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"time"
@@ -13,8 +13,14 @@ import (
 	"github.com/powerman/structlog"
 
 	"git.arilot.com/kuberstack/kuberstack-installer/db"
+	"git.arilot.com/kuberstack/kuberstack-installer/kops"
 	"git.arilot.com/kuberstack/kuberstack-installer/savedstate"
+	"git.arilot.com/kuberstack/kuberstack-installer/steps"
 	"git.arilot.com/kuberstack/kuberstack-installer/steps/auth"
+	"git.arilot.com/kuberstack/kuberstack-installer/steps/network"
+	"git.arilot.com/kuberstack/kuberstack-installer/steps/nodes"
+	"git.arilot.com/kuberstack/kuberstack-installer/steps/validation"
+	"git.arilot.com/kuberstack/kuberstack-installer/steps/versions"
 )
 
 const (
@@ -25,7 +31,7 @@ const (
 func Install(
 	conn db.Connect,
 	principal savedstate.Principal,
-	itself string,
+	executor kops.Executor,
 	tmpDir string,
 	timeout time.Duration,
 	logger *structlog.Logger,
@@ -55,66 +61,31 @@ func Install(
 	}
 	logger.Debug("SSH key saved", "file", filepath.Join(homeDir, sshKeyFile))
 
-	notSetErr := make([]string, 0, 11)
-
-	if len(principal.Sess.Name) == 0 {
-		notSetErr = append(notSetErr, "Name")
-	}
-	if len(principal.Sess.Domain) == 0 {
-		notSetErr = append(notSetErr, "Domain")
-	}
-	if len(principal.Sess.Bucket) == 0 {
-		notSetErr = append(notSetErr, "Bucket")
-	}
-	if principal.Sess.Master.Quantity == 0 {
-		notSetErr = append(notSetErr, "Master.Quantity")
-	}
-	if len(principal.Sess.Master.Type) == 0 {
-		notSetErr = append(notSetErr, "Master.Type")
-	}
-	if principal.Sess.Master.StorageSize == 0 {
-		notSetErr = append(notSetErr, "Master.StorageSize")
-	}
-	if len(principal.Sess.Master.Zones) == 0 {
-		notSetErr = append(notSetErr, "Master.Zones")
-	}
-	if principal.Sess.Nodes.Quantity == 0 {
-		notSetErr = append(notSetErr, "Nodes.Quantity")
-	}
-	if len(principal.Sess.Nodes.Type) == 0 {
-		notSetErr = append(notSetErr, "Nodes.Type")
-	}
-	if principal.Sess.Nodes.StorageSize == 0 {
-		notSetErr = append(notSetErr, "Nodes.StorageSize")
+	errs := validation.Validate(principal.Sess)
+	if principal.Sess.ExportTarget != "" {
+		errs.Add("exportTarget", steps.CodeConflict, "Cluster is exported to %s, it is created by applying the code", principal.Sess.ExportTarget)
 	}
-	if len(principal.Sess.Nodes.Zones) == 0 {
-		notSetErr = append(notSetErr, "Nodes.Zones")
-	}
-	if len(principal.Sess.SSHPubKey) == 0 {
-		notSetErr = append(notSetErr, "SSH public key")
+	if len(errs) > 0 {
+		return logger.Err(errs)
 	}
 
-	if len(notSetErr) > 0 {
-		return logger.Err(fmt.Errorf("Requred parameter(s) not set: %v", notSetErr))
-	}
+	versions.Resolve(principal.Sess)
 
-	clusterName := principal.Sess.Name + "." + principal.Sess.Domain
-	if strings.HasSuffix(clusterName, ".") {
-		clusterName = clusterName[:len(clusterName)-1]
+	// the resolved versions are saved before doInstall starts reading and saving the state on its own
+	err = conn.SaveState(principal.ID, principal.Sess)
+	if err != nil {
+		return err
 	}
 
+	// the install outlives the request, so it is not canceled along with the request context
 	go doInstall(
+		context.Background(),
 		conn,
 		principal.ID,
-		homeDir,
-		itself,
-		timeout,
-		clusterName,
-		principal.Sess,
-		logger,
+		newKopsRunner(conn, principal, "install", executor, tmpDir, timeout, logger),
 	)
 
-	return conn.SaveState(principal.ID, principal.Sess)
+	return nil
 }
 
 func saveSSHkey(key string, fileName string, logger *structlog.Logger) error {
@@ -141,71 +112,32 @@ func mustClose(f io.Closer) {
 	}
 }
 
-func doInstall(
-	conn db.Connect,
-	id string,
-	homeDir string,
-	itself string,
-	timeout time.Duration,
-	clusterName string,
-	sess *savedstate.State,
-	logger *structlog.Logger,
-) {
+func doInstall(ctx context.Context, conn db.Connect, id string, r kopsRunner) {
 	// Create //////////////////////////////////////////////////////////////
-	cmdParams := []string{
-		"--kopsCreate",
-		fmt.Sprintf("--name=%v", clusterName),
-		fmt.Sprintf("--timeout=%v", timeout),
-		fmt.Sprintf("--state=s3://%v", sess.Bucket),
-		fmt.Sprintf("--master-count=%v", sess.Master.Quantity),
-		fmt.Sprintf("--master-size=%v", sess.Master.Type),
-		fmt.Sprintf("--master-volume-size=%v", sess.Master.StorageSize),
-		fmt.Sprintf("--master-zones=%v", strings.Join(sess.Master.Zones, ",")),
-		fmt.Sprintf("--node-count=%v", sess.Nodes.Quantity),
-		fmt.Sprintf("--node-size=%v", sess.Nodes.Type),
-		fmt.Sprintf("--node-volume-size=%v", sess.Nodes.StorageSize),
-		fmt.Sprintf("--zones=%v", strings.Join(sess.Nodes.Zones, ",")),
-		fmt.Sprintf("--ssh-public-key=%v", filepath.Join(homeDir, sshKeyFile)),
-	}
-
-	cmdEnv := []string{
-		fmt.Sprintf("HOME=%v", homeDir),
-		// ToDo: replace with file in $HOME
-		fmt.Sprintf("AWS_ACCESS_KEY=%v", sess.AccessKey),
-		fmt.Sprintf("AWS_SECRET_KEY=%v", sess.SecretKey),
-	}
-
-	cmd := exec.Command(itself, cmdParams...) // #nosec
-	cmd.Env = cmdEnv
-
-	logger.Debug("Calling Kops create", "params", cmdParams)
-	cmdOut, err := cmd.CombinedOutput()
+	err := r.run(ctx, createConfig(r.homeDir, r.sess))
 	if err != nil {
-		logger.PrintErr("Kops create failed", "err", err, "out", string(cmdOut))
 		setStatus(id, StatusFailed)
 		return
 	}
 
-	logger.Debug("Kops create done", "out", string(cmdOut))
-
 	// Save config //////////////////////////////////////////////////////////////
-	kubecfgName := filepath.Join(homeDir, ".kube", "config")
+	kubecfgName := filepath.Join(r.homeDir, ".kube", "config")
 	kubecfg, err := ioutil.ReadFile(kubecfgName)
 	if err != nil {
-		logger.PrintErr("Reading kubecfg error", "err", err, "file", kubecfgName)
+		r.logger.PrintErr("Reading kubecfg error", "err", err, "file", kubecfgName)
 		setStatus(id, StatusFailed)
 		return
 	}
 
 	principal, err := auth.GetSession(conn, id)
 	if err != nil {
-		logger.PrintErr("Reading DB record error", "err", err)
+		r.logger.PrintErr("Reading DB record error", "err", err)
 		setStatus(id, StatusFailed)
 		return
 	}
 
 	if principal == nil {
-		logger.PrintErr("Empty DB record error")
+		r.logger.PrintErr("Empty DB record error")
 		setStatus(id, StatusFailed)
 		return
 	}
@@ -214,56 +146,87 @@ func doInstall(
 
 	err = conn.SaveState(principal.ID, principal.Sess)
 	if err != nil {
-		logger.PrintErr("Saving DB record error", "err", err)
+		r.logger.PrintErr("Saving DB record error", "err", err)
 		setStatus(id, StatusFailed)
 		return
 	}
 
 	setStatus(id, StatusCreated)
 
-	logger.Debug("Kubecfg saved to db", "len", len(principal.Sess.Kubecfg))
+	r.logger.Debug("Kubecfg saved to db", "len", len(principal.Sess.Kubecfg))
 
-	// Update //////////////////////////////////////////////////////////////
-	cmdParams = []string{
-		"--kopsUpdate",
-		fmt.Sprintf("--name=%v", clusterName),
-		fmt.Sprintf("--state=s3://%v", sess.Bucket),
-		fmt.Sprintf("--timeout=%v", timeout),
+	// Bastion //////////////////////////////////////////////////////////////
+	err = setupBastion(ctx, r)
+	if err != nil {
+		setStatus(id, StatusFailed)
+		return
 	}
 
-	logger.Debug("Calling Kops update", "params", cmdParams)
-	cmd = exec.Command(itself, cmdParams...) // #nosec
-	cmd.Env = cmdEnv
-
-	cmdOut, err = cmd.CombinedOutput()
+	// Instance groups //////////////////////////////////////////////////////////////
+	err = createGroups(ctx, r)
 	if err != nil {
-		logger.PrintErr("Kops update failed", "err", err, "out", string(cmdOut))
 		setStatus(id, StatusFailed)
 		return
 	}
 
-	logger.Debug("Kops update done", "out", string(cmdOut))
+	// Cluster autoscaler //////////////////////////////////////////////////////////////
+	if autoscalerEnabled(r.sess) {
+		err = setupAutoscaler(ctx, r)
+		if err != nil {
+			setStatus(id, StatusFailed)
+			return
+		}
+	}
+
+	// Update //////////////////////////////////////////////////////////////
+	err = r.run(ctx, kops.Config{KopsUpdate: true})
+	if err != nil {
+		setStatus(id, StatusFailed)
+		return
+	}
 
 	// // Rolling //////////////////////////////////////////////////////////////
-	// cmdParams = []string{
-	// 	"--kopsRolling",
-	// 	fmt.Sprintf("--name=%v", clusterName),
-	// 	fmt.Sprintf("--state=s3://%v", sess.Bucket),
-	// 	fmt.Sprintf("--timeout=%v", timeout),
+	// err = r.run(ctx, kops.Config{KopsRolling: true})
+	// if err != nil {
+	// 	setStatus(id, StatusFailed)
+	// 	return
 	// }
-	//
-	// 	logger.Debug("Calling Kops rolling", "params", cmdParams)
-	// 	cmd = exec.Command(itself, cmdParams...) // #nosec
-	// 	cmd.Env = cmdEnv
-	//
-	// 	cmdOut, err = cmd.CombinedOutput()
-	// 	if err != nil {
-	// 		logger.PrintErr("Kops rolling failed", "err", err, "out", string(cmdOut))
-	// 		setStatus(id, StatusFailed)
-	// 		return
-	// 	}
-	//
-	// 	logger.Debug("Kops update done", "out", string(cmdOut))
 
 	setStatus(id, StatusRolled)
 }
+
+// createConfig returns the kops create config of the cluster spec saved
+func createConfig(homeDir string, sess *savedstate.State) kops.Config {
+	config := kops.Config{
+		KopsCreate:       true,
+		MasterCount:      int32(sess.Master.Quantity),
+		MasterSize:       sess.Master.Type,
+		MasterVolumeSize: int32(sess.Master.StorageSize),
+		MasterZones:      strings.Join(sess.Master.Zones, ","),
+		NodeCount:        int32(sess.Nodes.Quantity),
+		NodeSize:         sess.Nodes.Type,
+		NodeVolumeSize:   int32(sess.Nodes.StorageSize),
+		Zones:            strings.Join(sess.Nodes.Zones, ","),
+		SSHPublicKey:     filepath.Join(homeDir, sshKeyFile),
+		CloudLabels:      sess.CloudLabels(),
+		// all the groups created are of the masters image, the nodes one is fixed by createGroups
+		Image:             nodes.GroupImage(sess.Master),
+		Topology:          sess.Topology(),
+		SSHAccess:         strings.Join(network.SSHAccess(sess), ","),
+		AdminAccess:       strings.Join(network.APIAccess(sess), ","),
+		Authorization:     network.Authorization(sess),
+		APILoadBalancer:   network.APILoadBalancer(sess),
+		Networking:        network.Networking(sess),
+		KubernetesVersion: sess.KubernetesVersion,
+		Channel:           sess.Channel,
+		Bastion:           sess.Bastion != nil,
+	}
+
+	if sess.Network != nil {
+		config.VPC = sess.Network.VPCID
+		config.NetworkCIDR = sess.Network.CIDR
+		config.Subnets = strings.Join(sess.Network.SubnetIDs(), ",")
+	}
+
+	return config
+}
@@ -0,0 +1,130 @@
+package install
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"git.arilot.com/kuberstack/kuberstack-installer/kops"
+)
+
+// commands returns the kops commands called in order
+func commands(executor *kops.FakeExecutor) []string {
+	var res []string
+	for _, call := range executor.Calls() {
+		res = append(res, call.Config.Command())
+	}
+	return res
+}
+
+// writeKubecfg puts the kubecfg where kops create writes it
+func writeKubecfg(t *testing.T, r kopsRunner) {
+	dir := filepath.Join(r.homeDir, ".kube")
+	err := os.MkdirAll(dir, 0700)
+	if err == nil {
+		err = ioutil.WriteFile(filepath.Join(dir, "config"), []byte("kubecfg"), 0600)
+	}
+	if err != nil {
+		t.Fatalf("Kubecfg write error: %v", err)
+	}
+}
+
+func TestDoInstall(t *testing.T) {
+	executor := kops.NewFakeExecutor()
+	conn := newMemConn()
+	r, cleanup := testRunner(t, "install", conn, executor, time.Minute)
+	defer cleanup()
+	writeKubecfg(t, r)
+
+	doInstall(context.Background(), conn, "install", r)
+
+	if status := getStatus("install"); status != StatusRolled {
+		t.Errorf("Status = %d, want %d", status, StatusRolled)
+	}
+
+	if cmds := commands(executor); !reflect.DeepEqual(cmds, []string{"Create", "Update"}) {
+		t.Errorf("Commands = %q, want create and update", cmds)
+	}
+
+	sess, _ := conn.GetState("install")
+	if string(sess.Kubecfg) != "kubecfg" {
+		t.Errorf("Kubecfg = %q, want the one kops written", sess.Kubecfg)
+	}
+}
+
+func TestDoInstallErrors(t *testing.T) {
+	tests := []struct {
+		desc    string
+		command string
+		err     error
+		kubecfg bool
+		calls   []string
+		created bool
+	}{
+		{
+			desc:    "create failed",
+			command: "Create",
+			err:     errors.New("exit status 1"),
+			kubecfg: true,
+			calls:   []string{"Create"},
+		},
+		{
+			desc:    "create timeout",
+			command: "Create",
+			err:     &kops.RunError{Command: "Create", ExitCode: -1, Err: context.DeadlineExceeded},
+			kubecfg: true,
+			calls:   []string{"Create"},
+		},
+		{
+			desc:  "no kubecfg",
+			calls: []string{"Create"},
+		},
+		{
+			desc:    "update canceled",
+			command: "Update",
+			err:     &kops.RunError{Command: "Update", ExitCode: -1, Err: context.Canceled},
+			kubecfg: true,
+			calls:   []string{"Create", "Update"},
+			created: true,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		id := "install-" + tc.desc
+		t.Run(tc.desc, func(t *testing.T) {
+			executor := kops.NewFakeExecutor()
+			if tc.err != nil {
+				executor.Errors[tc.command] = tc.err
+			}
+
+			conn := newMemConn()
+			r, cleanup := testRunner(t, id, conn, executor, time.Minute)
+			defer cleanup()
+			if tc.kubecfg {
+				writeKubecfg(t, r)
+			}
+
+			doInstall(context.Background(), conn, id, r)
+
+			if status := getStatus(id); status != StatusFailed {
+				t.Errorf("Status = %d, want %d", status, StatusFailed)
+			}
+
+			if cmds := commands(executor); !reflect.DeepEqual(cmds, tc.calls) {
+				t.Errorf("Commands = %q, want %q", cmds, tc.calls)
+			}
+
+			// the cluster created is kept installed, so it is deleted and not created again
+			sess, _ := conn.GetState(id)
+			if (len(sess.Kubecfg) > 0) != tc.created {
+				t.Errorf("Kubecfg = %q, want it saved only once the cluster is created", sess.Kubecfg)
+			}
+		})
+	}
+}
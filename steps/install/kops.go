@@ -0,0 +1,143 @@
+package install
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	"github.com/powerman/structlog"
+	yaml "gopkg.in/yaml.v2"
+
+	"git.arilot.com/kuberstack/kuberstack-installer/db"
+	"git.arilot.com/kuberstack/kuberstack-installer/kops"
+	"git.arilot.com/kuberstack/kuberstack-installer/savedstate"
+)
+
+// kopsRunner runs the embedded kops in the session home dir with the session credentials,
+// every run is killed once the timeout is exceeded.
+// The output is kept in the session history as the steps of the job.
+type kopsRunner struct {
+	executor kops.Executor
+	conn     db.Connect
+	id       string
+	job      string
+	homeDir  string
+	timeout  time.Duration
+	sess     *savedstate.State
+	logger   *structlog.Logger
+}
+
+// newKopsRunner returns the runner of the new job, the job name is the flow calling kops, like install
+func newKopsRunner(
+	conn db.Connect,
+	principal savedstate.Principal,
+	jobName string,
+	executor kops.Executor,
+	tmpDir string,
+	timeout time.Duration,
+	logger *structlog.Logger,
+) kopsRunner {
+	return kopsRunner{
+		executor: executor,
+		conn:     conn,
+		id:       principal.ID,
+		job:      newJob(jobName),
+		homeDir:  filepath.Join(tmpDir, principal.ID),
+		timeout:  timeout,
+		sess:     principal.Sess,
+		logger:   logger,
+	}
+}
+
+// run runs the kops command the config is set for, the cluster name and the state store are set by the session
+func (r kopsRunner) run(ctx context.Context, config kops.Config) error {
+	_, err := r.output(ctx, config)
+	return err
+}
+
+// output runs the kops command and returns its stdout,
+// the log goes to stderr, so it is kept apart from the output
+func (r kopsRunner) output(ctx context.Context, config kops.Config) ([]byte, error) {
+	config.Name = r.sess.ClusterName()
+	config.State = r.sess.StateStore()
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	sink := r.newLogSink(config)
+
+	r.logger.Debug("Calling Kops", "params", config.Args())
+	res, err := r.executor.Run(
+		ctx,
+		config,
+		kops.Env{
+			HomeDir:   r.homeDir,
+			AccessKey: r.sess.AccessKey,
+			SecretKey: r.sess.SecretKey,
+			Output:    sink.output,
+		},
+	)
+	sink.flush()
+
+	if err != nil {
+		var out []byte
+		if res != nil {
+			out = res.Stderr
+		}
+		r.logger.PrintErr("Kops failed", "err", err, "params", config.Args(), "out", redact(string(out), r.sess))
+		if runErr, ok := err.(*kops.RunError); ok && (runErr.Timeout() || runErr.Canceled()) {
+			return nil, runErr
+		}
+		if reason := lastLine(out, r.sess); reason != "" {
+			return nil, fmt.Errorf("Kops %s failed: %s", config.Command(), reason)
+		}
+		return nil, fmt.Errorf("Kops %s failed", config.Command())
+	}
+
+	r.logger.Debug("Kops done", "params", config.Args(), "duration", res.Duration, "out", redact(string(res.Stderr), r.sess))
+
+	return res.Stdout, nil
+}
+
+// patchCluster applies the patch to the cluster manifest and replaces the cluster spec with it.
+// The changes are applied by the following cluster update.
+func patchCluster(
+	ctx context.Context,
+	r kopsRunner,
+	patch func(yaml.MapSlice) (yaml.MapSlice, error),
+) error {
+	out, err := r.output(ctx, kops.Config{KopsGetCluster: true})
+	if err != nil {
+		return err
+	}
+
+	var manifest yaml.MapSlice
+	err = yaml.Unmarshal(out, &manifest)
+	if err != nil {
+		r.logger.PrintErr("Cluster manifest parse error", "err", err)
+		return fmt.Errorf("Internal server error")
+	}
+
+	manifest, err = patch(manifest)
+	if err != nil {
+		r.logger.PrintErr("Cluster manifest patch error", "err", err)
+		return fmt.Errorf("Internal server error")
+	}
+
+	content, err := yaml.Marshal(manifest)
+	if err != nil {
+		r.logger.PrintErr("Cluster manifest marshal error", "err", err)
+		return fmt.Errorf("Internal server error")
+	}
+
+	fileName := filepath.Join(r.homeDir, "cluster.yaml")
+	err = ioutil.WriteFile(fileName, content, 0600)
+	if err != nil {
+		r.logger.PrintErr("Cluster manifest write error", "err", err, "file", fileName)
+		return fmt.Errorf("Internal server error")
+	}
+
+	return r.run(ctx, kops.Config{KopsReplaceCluster: true, Filename: fileName})
+}
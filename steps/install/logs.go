@@ -0,0 +1,284 @@
+package install
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/powerman/structlog"
+
+	"git.arilot.com/kuberstack/kuberstack-installer/db"
+	"git.arilot.com/kuberstack/kuberstack-installer/kops"
+	"git.arilot.com/kuberstack/kuberstack-installer/savedstate"
+	"git.arilot.com/kuberstack/kuberstack-installer/steps"
+)
+
+// Limits of the log entries returned at once
+const (
+	DefaultLogsLimit = 100
+	MaxLogsLimit     = 1000
+)
+
+const redacted = "[REDACTED]"
+
+var (
+	// secretRegexp matches the values of the secret-like keys, like "password: value" or "--token=value"
+	secretRegexp = regexp.MustCompile(`(?i)((?:secret|password|passwd|token|private[_-]?key|access[_-]?key)[\w-]*["']?\s*[:=]\s*["']?)[^\s"',]+`)
+	// awsKeyRegexp matches the AWS access key IDs
+	awsKeyRegexp = regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)
+)
+
+// logHub passes the log entries added to the streams of the session
+var logHub struct {
+	streams map[string]map[chan savedstate.LogEntry]struct{}
+	sync.Mutex
+}
+
+func init() {
+	logHub.streams = make(map[string]map[chan savedstate.LogEntry]struct{}, 16)
+}
+
+// subscribe returns the channel the entries of the session are passed to and the func to stop it
+func subscribe(id string) (chan savedstate.LogEntry, func()) {
+	ch := make(chan savedstate.LogEntry, 256)
+
+	logHub.Lock()
+	defer logHub.Unlock()
+
+	if logHub.streams[id] == nil {
+		logHub.streams[id] = make(map[chan savedstate.LogEntry]struct{})
+	}
+	logHub.streams[id][ch] = struct{}{}
+
+	return ch, func() {
+		logHub.Lock()
+		defer logHub.Unlock()
+
+		delete(logHub.streams[id], ch)
+		if len(logHub.streams[id]) == 0 {
+			delete(logHub.streams, id)
+		}
+	}
+}
+
+// publish passes the entry to the streams of the session,
+// the entry is dropped for the stream not keeping up, it is still in the history
+func publish(id string, entry savedstate.LogEntry) {
+	logHub.Lock()
+	defer logHub.Unlock()
+
+	for ch := range logHub.streams[id] {
+		select {
+		case ch <- entry:
+		default:
+		}
+	}
+}
+
+// newJob returns the ID of the new run of the flow
+func newJob(name string) string {
+	return name + "-" + strconv.FormatInt(time.Now().UnixNano()/int64(time.Millisecond), 10)
+}
+
+// the kops output is written to the history in batches, the batch is written once it is full,
+// the interval has passed since its first line or the step is done
+const (
+	logBatchSize     = 100
+	logFlushInterval = time.Second
+)
+
+// logSink keeps the kops output lines of the job step in the session history
+type logSink struct {
+	conn   db.Connect
+	id     string
+	job    string
+	step   string
+	sess   *savedstate.State
+	logger *structlog.Logger
+	// noStdout drops the manifests printed by the get commands
+	noStdout bool
+
+	mu      sync.Mutex
+	pending []savedstate.LogEntry
+	timer   *time.Timer
+}
+
+// output is the kops.Env Output callback
+func (s *logSink) output(stream string, line string) {
+	if s.noStdout && stream == kops.StreamStdout {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pending = append(
+		s.pending,
+		savedstate.LogEntry{
+			Time:   time.Now(),
+			Job:    s.job,
+			Step:   s.step,
+			Stream: stream,
+			Line:   redact(line, s.sess),
+		},
+	)
+
+	switch {
+	case len(s.pending) >= logBatchSize:
+		s.write()
+	case s.timer == nil:
+		s.timer = time.AfterFunc(logFlushInterval, s.flush)
+	}
+}
+
+// flush writes the lines pending, it is called once the step is done
+func (s *logSink) flush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.write()
+}
+
+// write adds the lines pending to the history and passes them to the streams, s.mu is held by the caller
+func (s *logSink) write() {
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	if len(s.pending) == 0 {
+		return
+	}
+
+	entries := s.pending
+	s.pending = nil
+
+	err := s.conn.AppendLogs(s.id, entries)
+	if err != nil {
+		s.logger.PrintErr("Kops log save error", "err", err, "job", s.job, "lines", len(entries))
+		return
+	}
+
+	for _, entry := range entries {
+		publish(s.id, entry)
+	}
+}
+
+// redact hides the session credentials and the secret-like values of the output
+func redact(out string, sess *savedstate.State) string {
+	for _, secret := range []string{sess.SecretKey, sess.AccessKey} {
+		if secret != "" {
+			out = strings.Replace(out, secret, redacted, -1)
+		}
+	}
+
+	out = secretRegexp.ReplaceAllString(out, "${1}"+redacted)
+
+	return awsKeyRegexp.ReplaceAllString(out, redacted)
+}
+
+// lastLine returns the last non-empty line of the redacted output, it is the kops failure reason mostly
+func lastLine(out []byte, sess *savedstate.State) string {
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	return redact(strings.TrimSpace(lines[len(lines)-1]), sess)
+}
+
+// GetLogs returns the kops output history of the session following the after entry,
+// only the entries of the job are returned if it is set
+func GetLogs(
+	conn db.Connect,
+	principal savedstate.Principal,
+	after uint64,
+	limit int64,
+	job string,
+) ([]savedstate.LogEntry, error) {
+	switch {
+	case limit == 0:
+		limit = DefaultLogsLimit
+	case limit < 0 || limit > MaxLogsLimit:
+		var errs steps.FieldErrors
+		errs.Add("limit", steps.CodeOutOfRange, "Limit must be from 1 to %d", MaxLogsLimit)
+		return nil, errs
+	}
+
+	return conn.GetLogs(principal.ID, after, int(limit), job)
+}
+
+// StreamLogs passes the kops output history of the session following the after entry to send
+// and then the entries as soon as they are added until the context is done or send fails
+func StreamLogs(
+	ctx context.Context,
+	conn db.Connect,
+	principal savedstate.Principal,
+	after uint64,
+	send func(savedstate.LogEntry) error,
+	logger *structlog.Logger,
+) error {
+	// subscribed before the history is read, so no entry is lost in between
+	ch, unsubscribe := subscribe(principal.ID)
+	defer unsubscribe()
+
+	// history sends the entries from the DB, the entries dropped or passed out of order are read from it too
+	history := func() error {
+		for {
+			entries, err := conn.GetLogs(principal.ID, after, MaxLogsLimit, "")
+			if err != nil {
+				logger.PrintErr("Kops log read error", "err", err, "id", principal.ID)
+				return fmt.Errorf("Internal server error")
+			}
+
+			for _, entry := range entries {
+				err = send(entry)
+				if err != nil {
+					return err
+				}
+				after = entry.Seq
+			}
+
+			if len(entries) < MaxLogsLimit {
+				return nil
+			}
+		}
+	}
+
+	err := history()
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case entry := <-ch:
+			switch {
+			case entry.Seq <= after:
+			case entry.Seq == after+1:
+				err = send(entry)
+				after = entry.Seq
+			default:
+				err = history()
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// newLogSink returns the history sink of the step,
+// the manifests printed by the get commands are not kept
+func (r kopsRunner) newLogSink(config kops.Config) *logSink {
+	return &logSink{
+		conn:     r.conn,
+		id:       r.id,
+		job:      r.job,
+		step:     config.Command(),
+		sess:     r.sess,
+		logger:   r.logger,
+		noStdout: config.KopsGetCluster || config.KopsGetGroup,
+	}
+}
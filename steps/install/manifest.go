@@ -0,0 +1,212 @@
+package install
+
+import (
+	"fmt"
+	"strconv"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"git.arilot.com/kuberstack/kuberstack-installer/savedstate"
+	"git.arilot.com/kuberstack/kuberstack-installer/steps/nodes"
+)
+
+const (
+	kopsAPIVersion        = "kops/v1alpha2"
+	kopsClusterLabel      = "kops.k8s.io/cluster"
+	instanceGroupKind     = "InstanceGroup"
+	instanceGroupRoleNode = "Node"
+	// spot instances are taken from the cheapest pools
+	spotAllocationStrategy = "lowest-price"
+	volumeFilesystem       = "ext4"
+)
+
+type manifestMetadata struct {
+	Name   string            `yaml:"name"`
+	Labels map[string]string `yaml:"labels,omitempty"`
+}
+
+type instanceGroupSpec struct {
+	Role        string            `yaml:"role"`
+	MachineType string            `yaml:"machineType"`
+	MinSize     int64             `yaml:"minSize"`
+	MaxSize     int64             `yaml:"maxSize"`
+	Subnets     []string          `yaml:"subnets"`
+	NodeLabels  map[string]string `yaml:"nodeLabels,omitempty"`
+	CloudLabels map[string]string `yaml:"cloudLabels,omitempty"`
+	Taints      []string          `yaml:"taints,omitempty"`
+	Image       string            `yaml:"image,omitempty"`
+
+	storageSpec `yaml:",inline"`
+
+	MaxPrice             string                `yaml:"maxPrice,omitempty"`
+	MixedInstancesPolicy *mixedInstancesPolicy `yaml:"mixedInstancesPolicy,omitempty"`
+}
+
+// storageSpec are the instance group volumes
+type storageSpec struct {
+	RootVolumeSize          int64             `yaml:"rootVolumeSize,omitempty"`
+	RootVolumeType          string            `yaml:"rootVolumeType,omitempty"`
+	RootVolumeIops          int64             `yaml:"rootVolumeIops,omitempty"`
+	RootVolumeThroughput    int64             `yaml:"rootVolumeThroughput,omitempty"`
+	RootVolumeEncryption    bool              `yaml:"rootVolumeEncryption,omitempty"`
+	RootVolumeEncryptionKey string            `yaml:"rootVolumeEncryptionKey,omitempty"`
+	Volumes                 []volumeSpec      `yaml:"volumes,omitempty"`
+	VolumeMounts            []volumeMountSpec `yaml:"volumeMounts,omitempty"`
+}
+
+type volumeSpec struct {
+	Device     string `yaml:"device"`
+	Size       int64  `yaml:"size"`
+	Type       string `yaml:"type"`
+	Iops       int64  `yaml:"iops,omitempty"`
+	Throughput int64  `yaml:"throughput,omitempty"`
+	Encrypted  bool   `yaml:"encrypted,omitempty"`
+	Key        string `yaml:"key,omitempty"`
+}
+
+type volumeMountSpec struct {
+	Device     string `yaml:"device"`
+	Filesystem string `yaml:"filesystem"`
+	Path       string `yaml:"path"`
+}
+
+type mixedInstancesPolicy struct {
+	Instances              []string `yaml:"instances"`
+	OnDemandBase           int64    `yaml:"onDemandBase"`
+	OnDemandAboveBase      int64    `yaml:"onDemandAboveBase"`
+	SpotAllocationStrategy string   `yaml:"spotAllocationStrategy"`
+}
+
+type instanceGroupManifest struct {
+	APIVersion string            `yaml:"apiVersion"`
+	Kind       string            `yaml:"kind"`
+	Metadata   manifestMetadata  `yaml:"metadata"`
+	Spec       instanceGroupSpec `yaml:"spec"`
+}
+
+// groupManifest returns the kops manifest of the instance group of nodes.
+// kops names the subnets after the zones with the public topology.
+// The cloud labels are set as the autoscaling group tags.
+func groupManifest(
+	clusterName string,
+	group savedstate.InstanceGroup,
+	cloudLabels map[string]string,
+) ([]byte, error) {
+	maxSize := group.MaxSize
+	if maxSize < group.Quantity {
+		maxSize = group.Quantity
+	}
+
+	manifest := &instanceGroupManifest{
+		APIVersion: kopsAPIVersion,
+		Kind:       instanceGroupKind,
+		Metadata: manifestMetadata{
+			Name:   group.Name,
+			Labels: map[string]string{kopsClusterLabel: clusterName},
+		},
+		Spec: instanceGroupSpec{
+			Role:        instanceGroupRoleNode,
+			MachineType: group.Type,
+			MinSize:     group.Quantity,
+			MaxSize:     maxSize,
+			Subnets:     group.Zones,
+			NodeLabels:  group.Labels,
+			CloudLabels: cloudLabels,
+			Taints:      group.Taints,
+			Image:       nodes.GroupImage(group.NodesParams),
+			storageSpec: toStorageSpec(group.NodesParams),
+		},
+	}
+
+	// Every instance above the on-demand base is a spot one
+	if spot := group.Spot; spot != nil {
+		if spot.MaxPrice > 0 {
+			manifest.Spec.MaxPrice = strconv.FormatFloat(spot.MaxPrice, 'f', -1, 64)
+		}
+		manifest.Spec.MixedInstancesPolicy = &mixedInstancesPolicy{
+			Instances:              append([]string{group.Type}, spot.Types...),
+			OnDemandBase:           spot.OnDemandBase,
+			OnDemandAboveBase:      0,
+			SpotAllocationStrategy: spotAllocationStrategy,
+		}
+	}
+
+	return yaml.Marshal(manifest)
+}
+
+// toStorageSpec returns the kops spec of the group volumes,
+// the data volumes with a path are formatted to ext4 and mounted
+func toStorageSpec(params savedstate.NodesParams) storageSpec {
+	spec := storageSpec{
+		RootVolumeSize:          params.StorageSize,
+		RootVolumeType:          params.StorageType,
+		RootVolumeIops:          params.StorageIOPS,
+		RootVolumeThroughput:    params.StorageThroughput,
+		RootVolumeEncryption:    params.StorageEncrypted,
+		RootVolumeEncryptionKey: params.StorageKMSKey,
+	}
+
+	for _, volume := range params.Volumes {
+		volumeType := volume.Type
+		if volumeType == "" {
+			volumeType = nodes.DefaultVolumeType
+		}
+
+		spec.Volumes = append(
+			spec.Volumes,
+			volumeSpec{
+				Device:     volume.Device,
+				Size:       volume.Size,
+				Type:       volumeType,
+				Iops:       volume.IOPS,
+				Throughput: volume.Throughput,
+				Encrypted:  volume.Encrypted,
+				Key:        volume.KMSKey,
+			},
+		)
+
+		if volume.Path != "" {
+			spec.VolumeMounts = append(
+				spec.VolumeMounts,
+				volumeMountSpec{Device: volume.Device, Filesystem: volumeFilesystem, Path: volume.Path},
+			)
+		}
+	}
+
+	return spec
+}
+
+// setSpecField sets the field of the cluster spec keeping the rest of the manifest as is
+func setSpecField(manifest yaml.MapSlice, key string, value interface{}) (yaml.MapSlice, error) {
+	spec, ok := getField(manifest, "spec").(yaml.MapSlice)
+	if !ok {
+		return nil, fmt.Errorf("Cluster manifest has no spec")
+	}
+
+	return setField(manifest, "spec", setField(spec, key, value)), nil
+}
+
+// getSpecField returns the field of the cluster spec or nil if there is no such field
+func getSpecField(manifest yaml.MapSlice, key string) interface{} {
+	spec, _ := getField(manifest, "spec").(yaml.MapSlice)
+	return getField(spec, key)
+}
+
+func getField(fields yaml.MapSlice, key string) interface{} {
+	for _, field := range fields {
+		if field.Key == key {
+			return field.Value
+		}
+	}
+	return nil
+}
+
+func setField(fields yaml.MapSlice, key string, value interface{}) yaml.MapSlice {
+	for i := range fields {
+		if fields[i].Key == key {
+			fields[i].Value = value
+			return fields
+		}
+	}
+	return append(fields, yaml.MapItem{Key: key, Value: value})
+}
@@ -0,0 +1,485 @@
+package install
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/powerman/structlog"
+	yaml "gopkg.in/yaml.v2"
+
+	"git.arilot.com/kuberstack/kuberstack-installer/db"
+	"git.arilot.com/kuberstack/kuberstack-installer/kops"
+	"git.arilot.com/kuberstack/kuberstack-installer/predefined"
+	"git.arilot.com/kuberstack/kuberstack-installer/savedstate"
+	"git.arilot.com/kuberstack/kuberstack-installer/steps"
+	"git.arilot.com/kuberstack/kuberstack-installer/steps/nodes"
+)
+
+// Manifest formats
+const (
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+)
+
+const clusterKind = "Cluster"
+
+var groupRoles = []string{nodes.RoleMaster, nodes.RoleNode, nodes.RoleBastion}
+
+// manifestSchema is the part of the kops manifest checked before it is passed to kops
+type manifestSchema struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	Metadata   struct {
+		Name string `yaml:"name"`
+	} `yaml:"metadata"`
+	Spec yaml.MapSlice `yaml:"spec"`
+}
+
+type clusterSpecSchema struct {
+	CloudProvider     string `yaml:"cloudProvider"`
+	KubernetesVersion string `yaml:"kubernetesVersion"`
+}
+
+type groupSpecSchema struct {
+	Role        string   `yaml:"role"`
+	MachineType string   `yaml:"machineType"`
+	MinSize     *int64   `yaml:"minSize"`
+	MaxSize     *int64   `yaml:"maxSize"`
+	Subnets     []string `yaml:"subnets"`
+}
+
+// GetClusterManifest returns the kops cluster manifest from the state store in the format requested
+func GetClusterManifest(
+	ctx context.Context,
+	conn db.Connect,
+	principal savedstate.Principal,
+	format string,
+	executor kops.Executor,
+	tmpDir string,
+	timeout time.Duration,
+	logger *structlog.Logger,
+) (string, error) {
+	logger = logger.New("id", principal.ID).AppendPrefixKeys("id")
+
+	manifest, err := getManifest(ctx, newKopsRunner(conn, principal, "manifest", executor, tmpDir, timeout, logger), "")
+	if err != nil {
+		return "", err
+	}
+
+	return formatManifest(manifest, format, logger)
+}
+
+// GetGroupManifest returns the kops instance group manifest from the state store in the format requested
+func GetGroupManifest(
+	ctx context.Context,
+	conn db.Connect,
+	principal savedstate.Principal,
+	name string,
+	format string,
+	executor kops.Executor,
+	tmpDir string,
+	timeout time.Duration,
+	logger *structlog.Logger,
+) (string, error) {
+	logger = logger.New("id", principal.ID).AppendPrefixKeys("id")
+
+	if name == "" {
+		var errs steps.FieldErrors
+		errs.Add("name", steps.CodeRequired, "Instance group name not set")
+		return "", errs
+	}
+
+	manifest, err := getManifest(ctx, newKopsRunner(conn, principal, "manifest", executor, tmpDir, timeout, logger), name)
+	if err != nil {
+		return "", err
+	}
+
+	return formatManifest(manifest, format, logger)
+}
+
+// SaveClusterManifest checks the edited cluster manifest and returns the unified diff against the current one.
+// Unless dryRun is set the cluster spec is replaced with the manifest and the cluster is updated,
+// the running instances are replaced on the next rolling update only.
+// The installer state is not changed.
+func SaveClusterManifest(
+	ctx context.Context,
+	conn db.Connect,
+	principal savedstate.Principal,
+	content string,
+	format string,
+	dryRun bool,
+	executor kops.Executor,
+	tmpDir string,
+	timeout time.Duration,
+	logger *structlog.Logger,
+) (string, error) {
+	logger = logger.New("id", principal.ID).AppendPrefixKeys("id")
+
+	edited, errs := parseManifest(content, format)
+	if len(errs) == 0 {
+		errs = checkClusterManifest(edited, principal.Sess.ClusterName())
+	}
+	if len(errs) > 0 {
+		return "", errs
+	}
+
+	return saveManifest(ctx, newKopsRunner(conn, principal, "manifest", executor, tmpDir, timeout, logger), "", edited, dryRun)
+}
+
+// SaveGroupManifest checks the edited instance group manifest and returns the unified diff against the current one.
+// Unless dryRun is set the group is replaced with the manifest and the cluster is updated,
+// the running instances are replaced on the next rolling update only.
+// The installer state is not changed, so the edits of the additional groups are lost on the next save of the group.
+func SaveGroupManifest(
+	ctx context.Context,
+	conn db.Connect,
+	principal savedstate.Principal,
+	name string,
+	content string,
+	format string,
+	dryRun bool,
+	executor kops.Executor,
+	tmpDir string,
+	timeout time.Duration,
+	logger *structlog.Logger,
+) (string, error) {
+	logger = logger.New("id", principal.ID).AppendPrefixKeys("id")
+
+	edited, errs := parseManifest(content, format)
+	if len(errs) == 0 {
+		errs = checkGroupManifest(edited, name)
+	}
+	if len(errs) > 0 {
+		return "", errs
+	}
+
+	return saveManifest(ctx, newKopsRunner(conn, principal, "manifest", executor, tmpDir, timeout, logger), name, edited, dryRun)
+}
+
+// getManifest returns the cluster manifest or the instance group one if the group name is set
+func getManifest(ctx context.Context, r kopsRunner, group string) (yaml.MapSlice, error) {
+	if !isInstalled(r.sess) {
+		var errs steps.FieldErrors
+		errs.Add("cluster", steps.CodeConflict, "Cluster is not installed")
+		return nil, errs
+	}
+
+	config := kops.Config{KopsGetCluster: true}
+	if group != "" {
+		config = kops.Config{KopsGetGroup: true, GroupName: group}
+	}
+
+	out, err := r.output(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest yaml.MapSlice
+	err = yaml.Unmarshal(out, &manifest)
+	if err != nil {
+		r.logger.PrintErr("Manifest parse error", "err", err, "group", group)
+		return nil, fmt.Errorf("Internal server error")
+	}
+
+	return manifest, nil
+}
+
+// saveManifest returns the diff of the edited manifest and replaces the cluster or the instance group with it
+func saveManifest(
+	ctx context.Context,
+	r kopsRunner,
+	group string,
+	edited yaml.MapSlice,
+	dryRun bool,
+) (string, error) {
+	current, err := getManifest(ctx, r, group)
+	if err != nil {
+		return "", err
+	}
+
+	currentContent, err := yaml.Marshal(current)
+	if err != nil {
+		r.logger.PrintErr("Manifest marshal error", "err", err, "group", group)
+		return "", fmt.Errorf("Internal server error")
+	}
+
+	editedContent, err := yaml.Marshal(edited)
+	if err != nil {
+		r.logger.PrintErr("Manifest marshal error", "err", err, "group", group)
+		return "", fmt.Errorf("Internal server error")
+	}
+
+	diff, err := difflib.GetUnifiedDiffString(
+		difflib.UnifiedDiff{
+			A:        difflib.SplitLines(string(currentContent)),
+			B:        difflib.SplitLines(string(editedContent)),
+			FromFile: "current",
+			ToFile:   "edited",
+			Context:  3,
+		},
+	)
+	if err != nil {
+		r.logger.PrintErr("Manifest diff error", "err", err, "group", group)
+		return "", fmt.Errorf("Internal server error")
+	}
+
+	if dryRun || diff == "" {
+		return diff, nil
+	}
+
+	action := kops.Config{KopsReplaceCluster: true, Filename: filepath.Join(r.homeDir, "cluster.yaml")}
+	if group != "" {
+		action = kops.Config{KopsReplaceGroup: true, Filename: filepath.Join(r.homeDir, "ig-"+group+".yaml")}
+	}
+
+	err = os.MkdirAll(r.homeDir, 0700)
+	if err != nil {
+		r.logger.PrintErr("Kops home dir creation error", "err", err)
+		return "", fmt.Errorf("Internal server error")
+	}
+
+	err = ioutil.WriteFile(action.Filename, editedContent, 0600)
+	if err != nil {
+		r.logger.PrintErr("Manifest write error", "err", err, "file", action.Filename)
+		return "", fmt.Errorf("Internal server error")
+	}
+
+	// the changes are applied bound by the runner timeout only, so the client gone does not stop kops halfway
+	err = r.run(context.Background(), action)
+	if err != nil {
+		return "", err
+	}
+
+	err = r.run(context.Background(), kops.Config{KopsUpdate: true})
+
+	return diff, err
+}
+
+// parseManifest parses the manifest of the format provided, YAML is the default one
+func parseManifest(content string, format string) (yaml.MapSlice, steps.FieldErrors) {
+	var errs steps.FieldErrors
+
+	switch format {
+	case "", FormatYAML, FormatJSON:
+	default:
+		errs.Add("format", steps.CodeUnsupported, "Manifest format is not supported: %q", format)
+		return nil, errs
+	}
+
+	// JSON is a subset of YAML
+	var manifest yaml.MapSlice
+	err := yaml.Unmarshal([]byte(content), &manifest)
+	if err != nil {
+		errs.Add("manifest", steps.CodeInvalid, "Manifest is not valid: %v", err)
+	}
+	if len(manifest) == 0 {
+		errs.Add("manifest", steps.CodeRequired, "Manifest is empty")
+	}
+
+	return manifest, errs
+}
+
+// checkClusterManifest checks the cluster manifest is of the cluster and has the required fields,
+// the rest of the spec is checked by kops
+func checkClusterManifest(manifest yaml.MapSlice, clusterName string) steps.FieldErrors {
+	var schema manifestSchema
+	errs := decodeManifest(manifest, &schema)
+	if len(errs) > 0 {
+		return errs
+	}
+
+	errs = checkManifestHeader(schema, clusterKind, clusterName)
+
+	var spec clusterSpecSchema
+	errs = append(errs, decodeSpec(schema.Spec, &spec)...)
+
+	if spec.CloudProvider != "" && spec.CloudProvider != "aws" {
+		errs.Add("manifest.spec.cloudProvider", steps.CodeUnsupported, "Cloud provider is not supported: %q", spec.CloudProvider)
+	}
+	if spec.KubernetesVersion != "" && predefined.GetKubernetesVersion(spec.KubernetesVersion) == nil {
+		errs.Add("manifest.spec.kubernetesVersion", steps.CodeUnsupported, "Kubernetes version is not supported: %q", spec.KubernetesVersion)
+	}
+
+	return errs
+}
+
+// checkGroupManifest checks the instance group manifest is of the group and has the required fields,
+// the rest of the spec is checked by kops
+func checkGroupManifest(manifest yaml.MapSlice, name string) steps.FieldErrors {
+	var schema manifestSchema
+	errs := decodeManifest(manifest, &schema)
+	if len(errs) > 0 {
+		return errs
+	}
+
+	errs = checkManifestHeader(schema, instanceGroupKind, name)
+
+	var spec groupSpecSchema
+	errs = append(errs, decodeSpec(schema.Spec, &spec)...)
+
+	if !steps.StrInSlice(spec.Role, groupRoles) {
+		errs.Add("manifest.spec.role", steps.CodeUnsupported, "Instance group role is not supported: %q", spec.Role)
+	}
+	switch {
+	case spec.Role == nodes.RoleBastion && !nodes.CheckBastionType(spec.MachineType):
+		errs.Add("manifest.spec.machineType", steps.CodeUnsupported, "Instance type is not supported: %q", spec.MachineType)
+	case spec.Role != nodes.RoleBastion && !nodes.CheckRoleType(spec.Role, spec.MachineType):
+		errs.Add("manifest.spec.machineType", steps.CodeUnsupported, "Instance type is not supported for %s: %q", spec.Role, spec.MachineType)
+	}
+
+	switch {
+	case spec.MinSize == nil || spec.MaxSize == nil:
+		errs.Add("manifest.spec.minSize", steps.CodeRequired, "Instance group min and max sizes not set")
+	case *spec.MinSize < 0 || *spec.MaxSize < *spec.MinSize:
+		errs.Add("manifest.spec.maxSize", steps.CodeOutOfRange, "Instance group max size should not be less than min size %d", *spec.MinSize)
+	}
+
+	if len(spec.Subnets) == 0 {
+		errs.Add("manifest.spec.subnets", steps.CodeRequired, "Instance group subnets not set")
+	}
+
+	return errs
+}
+
+func checkManifestHeader(schema manifestSchema, kind string, name string) steps.FieldErrors {
+	var errs steps.FieldErrors
+
+	if schema.APIVersion != kopsAPIVersion {
+		errs.Add("manifest.apiVersion", steps.CodeUnsupported, "API version should be %s, got %q", kopsAPIVersion, schema.APIVersion)
+	}
+	if schema.Kind != kind {
+		errs.Add("manifest.kind", steps.CodeInvalid, "Kind should be %s, got %q", kind, schema.Kind)
+	}
+	if schema.Metadata.Name != name {
+		errs.Add("manifest.metadata.name", steps.CodeConflict, "Name should be %q, got %q", name, schema.Metadata.Name)
+	}
+	if len(schema.Spec) == 0 {
+		errs.Add("manifest.spec", steps.CodeRequired, "Spec not set")
+	}
+
+	return errs
+}
+
+// decodeManifest decodes the manifest into the schema struct
+func decodeManifest(manifest yaml.MapSlice, schema interface{}) steps.FieldErrors {
+	var errs steps.FieldErrors
+
+	content, err := yaml.Marshal(manifest)
+	if err == nil {
+		err = yaml.Unmarshal(content, schema)
+	}
+	if err != nil {
+		errs.Add("manifest", steps.CodeInvalid, "Manifest does not match the schema: %v", err)
+	}
+
+	return errs
+}
+
+// decodeSpec decodes the manifest spec into the spec schema struct
+func decodeSpec(spec yaml.MapSlice, schema interface{}) steps.FieldErrors {
+	var errs steps.FieldErrors
+
+	content, err := yaml.Marshal(spec)
+	if err == nil {
+		err = yaml.Unmarshal(content, schema)
+	}
+	if err != nil {
+		errs.Add("manifest.spec", steps.CodeInvalid, "Spec does not match the schema: %v", err)
+	}
+
+	return errs
+}
+
+// formatManifest returns the manifest in the format requested, YAML is the default one
+func formatManifest(manifest yaml.MapSlice, format string, logger *structlog.Logger) (string, error) {
+	var (
+		content []byte
+		err     error
+	)
+
+	switch format {
+	case "", FormatYAML:
+		content, err = yaml.Marshal(manifest)
+	case FormatJSON:
+		content, err = json.MarshalIndent(jsonValue(manifest), "", "  ")
+	default:
+		var errs steps.FieldErrors
+		errs.Add("format", steps.CodeUnsupported, "Manifest format is not supported: %q", format)
+		return "", errs
+	}
+	if err != nil {
+		logger.PrintErr("Manifest marshal error", "err", err, "format", format)
+		return "", fmt.Errorf("Internal server error")
+	}
+
+	return string(content), nil
+}
+
+// jsonValue converts the YAML value to the one encoding/json is able to marshal,
+// the mappings keep the key order so the manifest saved back unchanged has no diff
+func jsonValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case yaml.MapSlice:
+		res := make(jsonObject, len(v))
+		for i, item := range v {
+			res[i] = jsonField{Key: fmt.Sprint(item.Key), Value: jsonValue(item.Value)}
+		}
+		return res
+	case map[interface{}]interface{}:
+		res := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			res[fmt.Sprint(key)] = jsonValue(item)
+		}
+		return res
+	case []interface{}:
+		res := make([]interface{}, len(v))
+		for i, item := range v {
+			res[i] = jsonValue(item)
+		}
+		return res
+	default:
+		return v
+	}
+}
+
+type jsonField struct {
+	Key   string
+	Value interface{}
+}
+
+// jsonObject is the JSON object marshaled with the fields in order
+type jsonObject []jsonField
+
+// MarshalJSON implements json.Marshaler
+func (o jsonObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, field := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		key, err := json.Marshal(field.Key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+
+		value, err := json.Marshal(field.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
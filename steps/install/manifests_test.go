@@ -0,0 +1,46 @@
+package install
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCheckGroupManifestMachineType(t *testing.T) {
+	tests := []struct {
+		role        string
+		machineType string
+		wantErr     bool
+	}{
+		{"Bastion", "t3.micro", false},
+		{"Bastion", "m5.large", false},
+		{"Node", "m5.large", false},
+		{"Master", "m5.large", false},
+		{"Node", "t3.micro", true},
+		{"Master", "t3.micro", true},
+		{"Node", "x9.large", true},
+	}
+
+	for _, tc := range tests {
+		content := fmt.Sprintf(`apiVersion: %s
+kind: %s
+metadata:
+  name: test
+spec:
+  role: %s
+  machineType: %s
+  minSize: 1
+  maxSize: 1
+  subnets: [eu-west-1a]
+`, kopsAPIVersion, instanceGroupKind, tc.role, tc.machineType)
+
+		manifest, errs := parseManifest(content, FormatYAML)
+		if len(errs) > 0 {
+			t.Fatalf("parseManifest errors: %v", errs)
+		}
+
+		errs = checkGroupManifest(manifest, "test")
+		if (len(errs) > 0) != tc.wantErr {
+			t.Errorf("checkGroupManifest(%s, %s) = %v, want error %v", tc.role, tc.machineType, errs, tc.wantErr)
+		}
+	}
+}
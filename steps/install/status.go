@@ -1,16 +1,15 @@
 package install
 
 import (
-	"fmt"
-	"os/exec"
-	"path/filepath"
+	"context"
 	"regexp"
-	"strings"
 	"sync"
 	"time"
 
 	"github.com/powerman/structlog"
 
+	"git.arilot.com/kuberstack/kuberstack-installer/db"
+	"git.arilot.com/kuberstack/kuberstack-installer/kops"
 	"git.arilot.com/kuberstack/kuberstack-installer/savedstate"
 	"net"
 )
@@ -50,12 +49,17 @@ func getStatus(id string) statusType {
 	return statuses.statuses[id]
 }
 
+// lookupHost resolves the API host, the tests replace it to run with no DNS
+var lookupHost = net.LookupHost
+
 var readyRegexp = regexp.MustCompile(`NODE\s+STATUS\s*\nNAME\s+ROLE\s+READY\s*\n(?:[^\s]+\s+(?:(?:node)|(?:master))\s+True\s*\n)+\s*\n`)
 
 // GetStatus returns a status of the ongoing install
 func GetStatus(
+	ctx context.Context,
+	conn db.Connect,
 	principal savedstate.Principal,
-	itself string,
+	executor kops.Executor,
 	tmpDir string,
 	timeout time.Duration,
 	logger *structlog.Logger,
@@ -65,49 +69,25 @@ func GetStatus(
 		return StatusReady, status
 	}
 
-	clusterName := principal.Sess.Name + "." + principal.Sess.Domain
-	if strings.HasSuffix(clusterName, ".") {
-		clusterName = clusterName[:len(clusterName)-1]
-	}
+	clusterName := principal.Sess.ClusterName()
 	apiHost := "api." + clusterName
 
-	res, err := net.LookupHost(apiHost)
+	res, err := lookupHost(apiHost)
 	if err != nil {
 		logger.Debug("Kubernetes API host has not domain resolve", "Host", apiHost)
 		return StatusReady, status
 	}
 	logger.Debug("Kubernetes API host resolved to", res)
 
-	homeDir := filepath.Join(tmpDir, principal.ID)
+	r := newKopsRunner(conn, principal, "status", executor, tmpDir, timeout, logger)
 
 	// Validate //////////////////////////////////////////////////////////////
-	cmdParams := []string{
-		"--kopsValidate",
-		fmt.Sprintf("--name=%v", clusterName),
-		fmt.Sprintf("--state=s3://%v", principal.Sess.Bucket),
-		fmt.Sprintf("--timeout=%v", timeout),
-	}
-
-	logger.Debug("Calling Kops validate", "params", cmdParams)
-
-	cmd := exec.Command(itself, cmdParams...) // #nosec
-	cmd.Env = []string{
-		fmt.Sprintf("HOME=%v", homeDir),
-		// ToDo: replace with file in $HOME
-		fmt.Sprintf("AWS_ACCESS_KEY=%v", principal.Sess.AccessKey),
-		fmt.Sprintf("AWS_SECRET_KEY=%v", principal.Sess.SecretKey),
-	}
-
-	cmdOut, err := cmd.CombinedOutput()
-
+	out, err := r.output(ctx, kops.Config{KopsValidate: true})
 	if err != nil {
-		logger.PrintErr("Kops validate failed", "err", err, "out", string(cmdOut))
 		return StatusReady, status
 	}
 
-	logger.Debug("Kops validate done", "out", string(cmdOut))
-
-	if !readyRegexp.Match(cmdOut) {
+	if !readyRegexp.Match(out) {
 		logger.Debug("Cluster is not ready yet", "total", StatusReady, "current", status)
 		return StatusReady, status
 	}
@@ -0,0 +1,104 @@
+package install
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/powerman/structlog"
+
+	"git.arilot.com/kuberstack/kuberstack-installer/kops"
+	"git.arilot.com/kuberstack/kuberstack-installer/savedstate"
+)
+
+const validateReady = `Using cluster from kubectl context: test.example.com
+
+NODE STATUS
+NAME				ROLE	READY
+ip-172-20-32-10.ec2.internal	master	True
+ip-172-20-40-11.ec2.internal	node	True
+
+Your cluster test.example.com is ready
+`
+
+const validateNotReady = `NODE STATUS
+NAME				ROLE	READY
+ip-172-20-32-10.ec2.internal	master	True
+ip-172-20-40-11.ec2.internal	node	False
+
+Validation Failed
+`
+
+func TestGetStatus(t *testing.T) {
+	defer func(lookup func(string) ([]string, error)) { lookupHost = lookup }(lookupHost)
+
+	tests := []struct {
+		desc     string
+		status   statusType
+		resolved bool
+		stdout   string
+		err      error
+		validate bool
+		want     statusType
+	}{
+		{desc: "creating", status: StatusCreated, resolved: true, want: StatusCreated},
+		{desc: "failed", status: StatusFailed, resolved: true, want: StatusFailed},
+		{desc: "ready", status: StatusReady, resolved: true, want: StatusReady},
+		{desc: "not resolved", status: StatusRolled, want: StatusRolled},
+		{desc: "validated", status: StatusRolled, resolved: true, stdout: validateReady, validate: true, want: StatusReady},
+		{desc: "not ready", status: StatusRolled, resolved: true, stdout: validateNotReady, validate: true, want: StatusRolled},
+		{
+			desc: "validate failed", status: StatusRolled, resolved: true, validate: true, want: StatusRolled,
+			err: errors.New("exit status 1"),
+		},
+		{
+			desc: "validate timeout", status: StatusRolled, resolved: true, validate: true, want: StatusRolled,
+			err: &kops.RunError{Command: "Validate", ExitCode: -1, Err: context.DeadlineExceeded},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		id := "status-" + tc.desc
+		t.Run(tc.desc, func(t *testing.T) {
+			lookupHost = func(host string) ([]string, error) {
+				if host != "api.test.example.com" {
+					t.Errorf("Host = %q, want the API one", host)
+				}
+				if !tc.resolved {
+					return nil, &net.DNSError{Err: "no such host", Name: host}
+				}
+				return []string{"192.0.2.1"}, nil
+			}
+
+			executor := kops.NewFakeExecutor()
+			executor.Results["Validate"] = &kops.Result{Stdout: []byte(tc.stdout)}
+			executor.Errors["Validate"] = tc.err
+
+			setStatus(id, tc.status)
+			defer DropStatus(id)
+
+			total, status := GetStatus(
+				context.Background(),
+				newMemConn(),
+				savedstate.Principal{ID: id, Sess: testSess()},
+				executor,
+				"",
+				time.Minute,
+				structlog.New(),
+			)
+			if total != StatusReady || status != tc.want {
+				t.Errorf("GetStatus = %d, %d, want %d, %d", total, status, StatusReady, tc.want)
+			}
+			if getStatus(id) != tc.want {
+				t.Errorf("Status kept = %d, want %d", getStatus(id), tc.want)
+			}
+
+			if validated := len(executor.Calls()) > 0; validated != tc.validate {
+				t.Errorf("Validated = %v, want %v", validated, tc.validate)
+			}
+		})
+	}
+}
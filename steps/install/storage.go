@@ -0,0 +1,86 @@
+package install
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"git.arilot.com/kuberstack/kuberstack-installer/kops"
+)
+
+// patchMasterGroups sets the masters storage options kops create has no flags for.
+// kops names the master groups after their zones, the rest of the group spec is kept as is.
+func patchMasterGroups(ctx context.Context, r kopsRunner) error {
+	if !r.sess.Master.HasStorageOptions() {
+		return nil
+	}
+
+	storage, err := storageFields(toStorageSpec(r.sess.Master))
+	if err != nil {
+		r.logger.PrintErr("Master storage spec error", "err", err)
+		return fmt.Errorf("Internal server error")
+	}
+
+	for _, zone := range r.sess.Master.Zones {
+		err = patchGroup(ctx, r, "master-"+zone, storage)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// patchGroup sets the fields of the instance group spec keeping the rest of the manifest as is
+func patchGroup(ctx context.Context, r kopsRunner, name string, fields yaml.MapSlice) error {
+	out, err := r.output(ctx, kops.Config{KopsGetGroup: true, GroupName: name})
+	if err != nil {
+		return err
+	}
+
+	var manifest yaml.MapSlice
+	err = yaml.Unmarshal(out, &manifest)
+	if err != nil {
+		r.logger.PrintErr("Instance group manifest parse error", "err", err, "group", name)
+		return fmt.Errorf("Internal server error")
+	}
+
+	for _, field := range fields {
+		manifest, err = setSpecField(manifest, field.Key.(string), field.Value)
+		if err != nil {
+			r.logger.PrintErr("Instance group manifest patch error", "err", err, "group", name)
+			return fmt.Errorf("Internal server error")
+		}
+	}
+
+	content, err := yaml.Marshal(manifest)
+	if err != nil {
+		r.logger.PrintErr("Instance group manifest marshal error", "err", err, "group", name)
+		return fmt.Errorf("Internal server error")
+	}
+
+	fileName := filepath.Join(r.homeDir, "ig-"+name+".yaml")
+	err = ioutil.WriteFile(fileName, content, 0600)
+	if err != nil {
+		r.logger.PrintErr("Instance group manifest write error", "err", err, "file", fileName)
+		return fmt.Errorf("Internal server error")
+	}
+
+	return r.run(ctx, kops.Config{KopsReplaceGroup: true, Filename: fileName})
+}
+
+// storageFields returns the storage spec as the manifest fields
+func storageFields(spec storageSpec) (yaml.MapSlice, error) {
+	content, err := yaml.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields yaml.MapSlice
+	err = yaml.Unmarshal(content, &fields)
+
+	return fields, err
+}
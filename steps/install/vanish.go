@@ -1,25 +1,25 @@
 package install
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/powerman/structlog"
 
-	awsSdk "github.com/aws/aws-sdk-go/aws"
 	"git.arilot.com/kuberstack/kuberstack-installer/db"
+	"git.arilot.com/kuberstack/kuberstack-installer/kops"
 	"git.arilot.com/kuberstack/kuberstack-installer/savedstate"
+	"git.arilot.com/kuberstack/kuberstack-installer/steps/validation"
+	awsSdk "github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/route53"
 
 	"git.arilot.com/kuberstack/kuberstack-installer/steps"
-	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/aws/client"
-	"crypto/sha256"
-	"encoding/hex"
+	"github.com/aws/aws-sdk-go/service/s3"
 )
 
 var (
@@ -30,7 +30,7 @@ var (
 func Vanish(
 	conn db.Connect,
 	principal savedstate.Principal,
-	itself string,
+	executor kops.Executor,
 	tmpDir string,
 	timeout time.Duration,
 	logger *structlog.Logger,
@@ -45,63 +45,15 @@ func Vanish(
 		return fmt.Errorf("Internal server error")
 	}
 
-	notSetErr := make([]string, 0, 11)
-
-	if len(principal.Sess.Name) == 0 {
-		notSetErr = append(notSetErr, "Name")
-	}
-	if len(principal.Sess.Domain) == 0 {
-		notSetErr = append(notSetErr, "Domain")
-	}
-	if len(principal.Sess.Bucket) == 0 {
-		notSetErr = append(notSetErr, "Bucket")
-	}
-	if principal.Sess.Master.Quantity == 0 {
-		notSetErr = append(notSetErr, "Master.Quantity")
-	}
-	if len(principal.Sess.Master.Type) == 0 {
-		notSetErr = append(notSetErr, "Master.Type")
-	}
-	if principal.Sess.Master.StorageSize == 0 {
-		notSetErr = append(notSetErr, "Master.StorageSize")
-	}
-	if len(principal.Sess.Master.Zones) == 0 {
-		notSetErr = append(notSetErr, "Master.Zones")
-	}
-	if principal.Sess.Nodes.Quantity == 0 {
-		notSetErr = append(notSetErr, "Nodes.Quantity")
-	}
-	if len(principal.Sess.Nodes.Type) == 0 {
-		notSetErr = append(notSetErr, "Nodes.Type")
-	}
-	if principal.Sess.Nodes.StorageSize == 0 {
-		notSetErr = append(notSetErr, "Nodes.StorageSize")
-	}
-	if len(principal.Sess.Nodes.Zones) == 0 {
-		notSetErr = append(notSetErr, "Nodes.Zones")
-	}
-	if len(principal.Sess.SSHPubKey) == 0 {
-		notSetErr = append(notSetErr, "SSH public key")
-	}
-
-	if len(notSetErr) > 0 {
-		return logger.Err(fmt.Errorf("Requred parameter(s) not set: %v", notSetErr))
-	}
-
-	clusterName := principal.Sess.Name + "." + principal.Sess.Domain
-	if strings.HasSuffix(clusterName, ".") {
-		clusterName = clusterName[:len(clusterName)-1]
+	errs := validation.ValidateCluster(principal.Sess)
+	if len(errs) > 0 {
+		return logger.Err(errs)
 	}
 
+	// the delete outlives the request, so it is not canceled along with the request context
 	go doDelete(
-		conn,
-		principal.ID,
-		homeDir,
-		itself,
-		timeout,
-		clusterName,
-		principal.Sess,
-		logger,
+		context.Background(),
+		newKopsRunner(conn, principal, "delete", executor, tmpDir, timeout, logger),
 	)
 
 	DropStatus(principal.ID)
@@ -109,41 +61,15 @@ func Vanish(
 	return nil
 }
 
-func doDelete(
-	conn db.Connect,
-	id string,
-	homeDir string,
-	itself string,
-	timeout time.Duration,
-	clusterName string,
-	sess *savedstate.State,
-	logger *structlog.Logger,
-) error {
-	cmdParams := []string{
-		"--kopsDelete",
-		fmt.Sprintf("--name=%v", clusterName),
-		fmt.Sprintf("--state=s3://%v", sess.Bucket),
-	}
-
-	cmdEnv := []string{
-		fmt.Sprintf("HOME=%v", homeDir),
-		// ToDo: replace with file in $HOME
-		fmt.Sprintf("AWS_ACCESS_KEY=%v", sess.AccessKey),
-		fmt.Sprintf("AWS_SECRET_KEY=%v", sess.SecretKey),
-	}
-
-	cmd := exec.Command(itself, cmdParams...) // #nosec
-	cmd.Env = cmdEnv
+func doDelete(ctx context.Context, r kopsRunner) error {
+	sess, logger := r.sess, r.logger
+	clusterName := sess.ClusterName()
 
-	logger.Debug("Calling Kops delete", "params", cmdParams)
-	cmdOut, err := cmd.CombinedOutput()
+	err := r.run(ctx, kops.Config{KopsDelete: true})
 	if err != nil {
-		logger.PrintErr("Kops delete failed", "err", err, "out", string(cmdOut))
 		return err
 	}
 
-	logger.Debug("Kops delete done", "out", string(cmdOut))
-
 	// Remove dns zone
 	awsSess, err := steps.AwsSession(
 		sess.AccessKey,
@@ -166,13 +92,13 @@ func doDelete(
 
 	logger.Debug("Zone deleted", "name", domainName, "Id", zoneID, "status", DNSZoneDeleteStatus, "cluster", clusterName)
 
-	err = deleteBucket(logger, awsSess, id, domainName)
+	err = deleteBucket(logger, awsSess, sess, clusterName)
 	if err != nil {
 		logger.PrintErr(err)
 		return err
 	}
 
-	logger.Debug("S3 bucket deleted", "cluster", clusterName)
+	logger.Debug("S3 state store cleaned up", "cluster", clusterName, "bucket", sess.Bucket, "existing", sess.BucketExisting)
 
 	return nil
 }
@@ -216,18 +142,29 @@ func deleteZone(r53 *route53.Route53, name string) (string, string, error) {
 	return zoneID, awsSdk.StringValue(res.ChangeInfo.Status), nil
 }
 
-func deleteBucket(logger *structlog.Logger, sess client.ConfigProvider, id string, domain string) (error) {
-	clnS3 := s3.New(sess)
-
-	idHash := sha256.Sum224([]byte(id))
+// deleteBucket removes the state store bucket created by the installer.
+// The bucket provided by the user is kept, only the cluster state
+// (including old object versions) is removed from it.
+func deleteBucket(
+	logger *structlog.Logger,
+	awsSess client.ConfigProvider,
+	sess *savedstate.State,
+	clusterName string,
+) error {
+	clnS3 := s3.New(awsSess)
 
-	bucketName := hex.EncodeToString(idHash[:])
+	bucketName := sess.Bucket
+	keyPrefix := ""
+	if sess.BucketExisting {
+		keyPrefix = strings.TrimPrefix(sess.StateStore()+"/"+clusterName+"/", "s3://"+bucketName+"/")
+	}
 
 	// Empty bucket
-	logger.Debug("removing objects from S3 bucket :", bucketName)
+	logger.Debug("removing objects from S3 bucket", "bucket", bucketName, "prefix", keyPrefix)
 
 	params := &s3.ListObjectsInput{
 		Bucket: awsSdk.String(bucketName),
+		Prefix: awsSdk.String(keyPrefix),
 	}
 	for {
 		objects, err := clnS3.ListObjects(params)
@@ -270,32 +207,33 @@ func deleteBucket(logger *structlog.Logger, sess client.ConfigProvider, id strin
 	logger.Debug("Emptied S3 bucket", "bucket", bucketName)
 
 	// Remove versions of files
-	logger.Debug("removing versions of files from S3 bucket :", bucketName)
+	logger.Debug("removing versions of files from S3 bucket", "bucket", bucketName, "prefix", keyPrefix)
 
-	params_versions := &s3.ListObjectVersionsInput{
+	paramsVersions := &s3.ListObjectVersionsInput{
 		Bucket: awsSdk.String(bucketName),
+		Prefix: awsSdk.String(keyPrefix),
 	}
 	for {
-		objects_versions, err := clnS3.ListObjectVersions(params_versions)
+		objectsVersions, err := clnS3.ListObjectVersions(paramsVersions)
 		if err != nil {
 			return err
 		}
 		//Checks if the bucket is already empty
-		if len((*objects_versions).Versions) == 0 && len((*objects_versions).DeleteMarkers) == 0 {
+		if len((*objectsVersions).Versions) == 0 && len((*objectsVersions).DeleteMarkers) == 0 {
 			logger.Debug("Bucket is already empty", "bucket", bucketName)
 			break
 		}
 
 		//creating an array of pointers of ObjectIdentifier
 		objectsToDelete := make([]*s3.ObjectIdentifier, 0, 1000)
-		for _, object := range (*objects_versions).Versions {
+		for _, object := range (*objectsVersions).Versions {
 			obj := s3.ObjectIdentifier{
 				Key:       object.Key,
 				VersionId: object.VersionId,
 			}
 			objectsToDelete = append(objectsToDelete, &obj)
 		}
-		for _, object := range (*objects_versions).DeleteMarkers {
+		for _, object := range (*objectsVersions).DeleteMarkers {
 			obj := s3.ObjectIdentifier{
 				Key:       object.Key,
 				VersionId: object.VersionId,
@@ -314,15 +252,20 @@ func deleteBucket(logger *structlog.Logger, sess client.ConfigProvider, id strin
 		if err != nil {
 			return err
 		}
-		if *(*objects_versions).IsTruncated { //if there are more objects in the bucket, IsTruncated = true
-			params.Marker = (*deleteParams).Delete.Objects[len((*deleteParams).Delete.Objects)-1].Key
-			logger.Debug("Requesting next batch | ", *(params.Marker))
+		if *(*objectsVersions).IsTruncated { //if there are more versions in the bucket, IsTruncated = true
+			paramsVersions.KeyMarker = objectsVersions.NextKeyMarker
+			paramsVersions.VersionIdMarker = objectsVersions.NextVersionIdMarker
+			logger.Debug("Requesting next batch | ", awsSdk.StringValue(paramsVersions.KeyMarker))
 		} else { //if all objects in the bucket have been cleaned up.
 			break
 		}
 	}
 	logger.Debug("Emptied S3 versions of files from bucket", "bucket", bucketName)
 
+	if sess.BucketExisting {
+		return nil
+	}
+
 	// Delete bucket
 	_, err := clnS3.DeleteBucket(
 		&s3.DeleteBucketInput{
@@ -330,9 +273,5 @@ func deleteBucket(logger *structlog.Logger, sess client.ConfigProvider, id strin
 		},
 	)
 
-	if err != nil {
-		return err
-	}
-
 	return err
-}
\ No newline at end of file
+}
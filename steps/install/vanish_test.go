@@ -0,0 +1,62 @@
+package install
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"git.arilot.com/kuberstack/kuberstack-installer/kops"
+)
+
+func TestDoDeleteKopsErrors(t *testing.T) {
+	tests := []struct {
+		desc     string
+		timeout  time.Duration
+		err      error
+		stderr   string
+		timedOut bool
+		want     string
+	}{
+		{
+			desc:    "delete failed",
+			timeout: time.Minute,
+			err:     errors.New("exit status 1"),
+			stderr:  "error: deleting cluster: AccessDenied\n",
+			want:    "Kops Delete failed: error: deleting cluster: AccessDenied",
+		},
+		{
+			desc:     "delete timeout",
+			timedOut: true,
+			want:     "Kops Delete timeout exceeded",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			executor := kops.NewFakeExecutor()
+			executor.Results["Delete"] = &kops.Result{Stderr: []byte(tc.stderr)}
+			executor.Errors["Delete"] = tc.err
+
+			r, cleanup := testRunner(t, "delete", newMemConn(), executor, tc.timeout)
+			defer cleanup()
+
+			// the zone and the bucket are kept, so the delete is retried with them
+			err := doDelete(context.Background(), r)
+			if err == nil || err.Error() != tc.want {
+				t.Fatalf("doDelete error = %v, want %q", err, tc.want)
+			}
+
+			runErr, ok := err.(*kops.RunError)
+			if ok != tc.timedOut || (ok && !runErr.Timeout()) {
+				t.Errorf("doDelete error = %#v, want the RunError on timeout only", err)
+			}
+
+			if cmds := commands(executor); !reflect.DeepEqual(cmds, []string{"Delete"}) {
+				t.Errorf("Commands = %q, want delete only", cmds)
+			}
+		})
+	}
+}
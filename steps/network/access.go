@@ -0,0 +1,113 @@
+package network
+
+import (
+	"net"
+
+	"git.arilot.com/kuberstack/kuberstack-installer/db"
+	"git.arilot.com/kuberstack/kuberstack-installer/savedstate"
+	"git.arilot.com/kuberstack/kuberstack-installer/steps"
+)
+
+// Kubernetes API authorization modes
+const (
+	AuthorizationRBAC        = "RBAC"
+	AuthorizationAlwaysAllow = "AlwaysAllow"
+)
+
+// Kubernetes API load balancer types, the internal one is reachable from the VPC only
+const (
+	LoadBalancerPublic   = "public"
+	LoadBalancerInternal = "internal"
+)
+
+// kops adds a security group rule per CIDR, a security group has up to 60 inbound rules
+const maxAccessCIDRs = 50
+
+// SaveAccess checks the API and SSH access options and saves them to the DB
+func SaveAccess(conn db.Connect, access savedstate.AccessParams, principal savedstate.Principal) error {
+	state := *principal.Sess
+	state.Access = access
+
+	errs := ValidateAccess(&state)
+	if len(errs) > 0 {
+		return errs
+	}
+
+	principal.Sess.Access = access
+
+	return conn.SaveState(principal.ID, principal.Sess)
+}
+
+// ValidateAccess checks the API and SSH access options
+func ValidateAccess(sess *savedstate.State) steps.FieldErrors {
+	var errs steps.FieldErrors
+
+	switch Authorization(sess) {
+	case AuthorizationRBAC, AuthorizationAlwaysAllow:
+	default:
+		errs.Add("access.authorization", steps.CodeUnsupported, "Authorization mode is not supported: %q", sess.Access.Authorization)
+	}
+
+	switch APILoadBalancer(sess) {
+	case LoadBalancerPublic, LoadBalancerInternal:
+	default:
+		errs.Add("access.apiLoadBalancer", steps.CodeUnsupported, "API load balancer type is not supported: %q", sess.Access.APILoadBalancer)
+	}
+
+	errs = append(errs, validateCIDRs("access.apiCidrs", sess.Access.APICIDRs)...)
+	errs = append(errs, validateCIDRs("access.sshCidrs", sess.Access.SSHCIDRs)...)
+
+	return errs
+}
+
+// validateCIDRs checks the access CIDRs. They are required: the access open to the world
+// should be an explicit 0.0.0.0/0 choice rather than a default.
+func validateCIDRs(field string, cidrs []string) steps.FieldErrors {
+	var errs steps.FieldErrors
+
+	if len(cidrs) == 0 {
+		errs.Add(field, steps.CodeRequired, "CIDRs access is allowed from should be set, 0.0.0.0/0 allows access from anywhere")
+	}
+
+	if len(cidrs) > maxAccessCIDRs {
+		errs.Add(field, steps.CodeOutOfRange, "No more than %d CIDRs allowed, got %d", maxAccessCIDRs, len(cidrs))
+	}
+
+	for _, cidr := range cidrs {
+		ip, _, err := net.ParseCIDR(cidr)
+		switch {
+		case err != nil:
+			errs.Add(field, steps.CodeInvalid, "CIDR is not valid: %q", cidr)
+		case ip.To4() == nil:
+			errs.Add(field, steps.CodeUnsupported, "IPv6 CIDRs are not supported: %q", cidr)
+		}
+	}
+
+	return errs
+}
+
+// Authorization returns the Kubernetes API authorization mode, RBAC by default
+func Authorization(sess *savedstate.State) string {
+	if sess.Access.Authorization == "" {
+		return AuthorizationRBAC
+	}
+	return sess.Access.Authorization
+}
+
+// APILoadBalancer returns the Kubernetes API load balancer type, public by default
+func APILoadBalancer(sess *savedstate.State) string {
+	if sess.Access.APILoadBalancer == "" {
+		return LoadBalancerPublic
+	}
+	return sess.Access.APILoadBalancer
+}
+
+// APIAccess returns the CIDRs the Kubernetes API access is allowed from
+func APIAccess(sess *savedstate.State) []string {
+	return sess.Access.APICIDRs
+}
+
+// SSHAccess returns the CIDRs SSH access to the nodes or to the bastion of the private cluster is allowed from
+func SSHAccess(sess *savedstate.State) []string {
+	return sess.Access.SSHCIDRs
+}
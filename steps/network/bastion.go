@@ -0,0 +1,94 @@
+package network
+
+import (
+	"regexp"
+
+	"git.arilot.com/kuberstack/kuberstack-installer/db"
+	"git.arilot.com/kuberstack/kuberstack-installer/savedstate"
+	"git.arilot.com/kuberstack/kuberstack-installer/steps"
+	"git.arilot.com/kuberstack/kuberstack-installer/steps/nodes"
+)
+
+// Bastion defaults
+const (
+	DefaultBastionType    = "t3.micro"
+	DefaultBastionDNSName = "bastion"
+	// kops names the bastion instance group
+	BastionGroup = "bastions"
+	// SSHPort is the port both the bastion and the public topology nodes listen SSH on
+	SSHPort = 22
+)
+
+var dnsLabelRegexp = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// SaveBastion checks the bastion options and saves them to the DB,
+// the cluster is switched to the private topology with the bastion enabled and to the public one otherwise
+func SaveBastion(
+	conn db.Connect,
+	enabled bool,
+	params savedstate.BastionParams,
+	principal savedstate.Principal,
+) error {
+	if !enabled {
+		principal.Sess.Bastion = nil
+		return conn.SaveState(principal.ID, principal.Sess)
+	}
+
+	if params.Type == "" {
+		params.Type = DefaultBastionType
+	}
+	if params.DNSName == "" {
+		params.DNSName = DefaultBastionDNSName
+	}
+
+	state := *principal.Sess
+	state.Bastion = &params
+
+	errs := append(ValidateBastion(&state), ValidateNetworking(&state)...)
+	if len(errs) > 0 {
+		return errs
+	}
+
+	principal.Sess.Bastion = &params
+
+	return conn.SaveState(principal.ID, principal.Sess)
+}
+
+// ValidateBastion checks the bastion options against the rest of the cluster spec
+func ValidateBastion(sess *savedstate.State) steps.FieldErrors {
+	var errs steps.FieldErrors
+
+	bastion := sess.Bastion
+	if bastion == nil {
+		return errs
+	}
+
+	if sess.Network != nil {
+		errs.Add("bastion", steps.CodeUnsupported, "Bastion host requires the private topology, it is not supported with an existing VPC yet")
+	}
+
+	switch {
+	case !nodes.CheckBastionType(bastion.Type):
+		errs.Add("bastion.instanceType", steps.CodeUnsupported, "Instance type is not supported for the bastion: %q", bastion.Type)
+	case sess.Master.Type != "" && nodes.Architecture(bastion.Type) != nodes.Architecture(sess.Master.Type):
+		// kops creates the bastion of the masters image
+		errs.Add(
+			"bastion.instanceType",
+			steps.CodeConflict,
+			"Bastion instance type %q should be of the masters architecture %s",
+			bastion.Type,
+			nodes.Architecture(sess.Master.Type),
+		)
+	}
+
+	if !dnsLabelRegexp.MatchString(bastion.DNSName) {
+		errs.Add("bastion.dnsName", steps.CodeInvalid, "Bastion DNS name should be a valid DNS label: %q", bastion.DNSName)
+	}
+
+	return errs
+}
+
+// BastionName returns the bastion DNS name under the cluster domain
+func BastionName(sess *savedstate.State) string {
+	return sess.Bastion.DNSName + "." + sess.ClusterName()
+}
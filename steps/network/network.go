@@ -0,0 +1,150 @@
+// Package network handles the cluster network: the existing VPC and subnets the cluster is deployed into
+// and the bastion host of the private topology
+package network
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+
+	"git.arilot.com/kuberstack/kuberstack-installer/savedstate"
+	"git.arilot.com/kuberstack/kuberstack-installer/steps"
+)
+
+// VPC is an existing VPC of the region
+type VPC struct {
+	ID        string
+	CIDR      string
+	Name      string
+	IsDefault bool
+}
+
+// Subnet is an existing subnet of the VPC,
+// the public subnets are routed to an internet gateway
+type Subnet struct {
+	ID           string
+	Zone         string
+	CIDR         string
+	Name         string
+	Public       bool
+	AvailableIPs int64
+}
+
+// GetVPCs returns a list of VPCs of the session region
+func GetVPCs(principal savedstate.Principal) ([]VPC, error) {
+	sess, err := steps.AwsSession(principal.Sess.AccessKey, principal.Sess.SecretKey, principal.Sess.Region)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := ec2.New(sess).DescribeVpcs(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	vpcs := make([]VPC, 0, len(res.Vpcs))
+	for _, vpc := range res.Vpcs {
+		vpcs = append(
+			vpcs,
+			VPC{
+				ID:        aws.StringValue(vpc.VpcId),
+				CIDR:      aws.StringValue(vpc.CidrBlock),
+				Name:      nameTag(vpc.Tags),
+				IsDefault: aws.BoolValue(vpc.IsDefault),
+			},
+		)
+	}
+
+	sort.Slice(vpcs, func(i, j int) bool { return vpcs[i].ID < vpcs[j].ID })
+
+	return vpcs, nil
+}
+
+// GetSubnets returns a list of subnets of the VPC sorted by zones
+func GetSubnets(vpcID string, principal savedstate.Principal) ([]Subnet, error) {
+	sess, err := steps.AwsSession(principal.Sess.AccessKey, principal.Sess.SecretKey, principal.Sess.Region)
+	if err != nil {
+		return nil, err
+	}
+
+	return getSubnets(ec2.New(sess), vpcID)
+}
+
+func getSubnets(svc *ec2.EC2, vpcID string) ([]Subnet, error) {
+	vpcFilter := []*ec2.Filter{{Name: aws.String("vpc-id"), Values: aws.StringSlice([]string{vpcID})}}
+
+	res, err := svc.DescribeSubnets(&ec2.DescribeSubnetsInput{Filters: vpcFilter})
+	if err != nil {
+		return nil, err
+	}
+
+	tables, err := svc.DescribeRouteTables(&ec2.DescribeRouteTablesInput{Filters: vpcFilter})
+	if err != nil {
+		return nil, err
+	}
+
+	subnets := make([]Subnet, 0, len(res.Subnets))
+	for _, subnet := range res.Subnets {
+		id := aws.StringValue(subnet.SubnetId)
+		subnets = append(
+			subnets,
+			Subnet{
+				ID:           id,
+				Zone:         aws.StringValue(subnet.AvailabilityZone),
+				CIDR:         aws.StringValue(subnet.CidrBlock),
+				Name:         nameTag(subnet.Tags),
+				Public:       isPublic(routeTable(tables.RouteTables, id)),
+				AvailableIPs: aws.Int64Value(subnet.AvailableIpAddressCount),
+			},
+		)
+	}
+
+	sort.Slice(
+		subnets,
+		func(i, j int) bool {
+			if subnets[i].Zone != subnets[j].Zone {
+				return subnets[i].Zone < subnets[j].Zone
+			}
+			return subnets[i].ID < subnets[j].ID
+		},
+	)
+
+	return subnets, nil
+}
+
+// routeTable returns the route table of the subnet,
+// the subnets with no table associated explicitly use the main one
+func routeTable(tables []*ec2.RouteTable, subnetID string) *ec2.RouteTable {
+	var main *ec2.RouteTable
+	for _, table := range tables {
+		for _, assoc := range table.Associations {
+			if aws.StringValue(assoc.SubnetId) == subnetID {
+				return table
+			}
+			if aws.BoolValue(assoc.Main) {
+				main = table
+			}
+		}
+	}
+	return main
+}
+
+// isPublic checks the default route goes to an internet gateway
+func isPublic(table *ec2.RouteTable) bool {
+	if table == nil {
+		return false
+	}
+	for _, route := range table.Routes {
+		if aws.StringValue(route.DestinationCidrBlock) == "0.0.0.0/0" &&
+			strings.HasPrefix(aws.StringValue(route.GatewayId), "igw-") {
+			return true
+		}
+	}
+	return false
+}
+
+func nameTag(tags []*ec2.Tag) string {
+	return tagValue(tags, "Name")
+}
@@ -0,0 +1,81 @@
+package network
+
+import (
+	"git.arilot.com/kuberstack/kuberstack-installer/db"
+	"git.arilot.com/kuberstack/kuberstack-installer/savedstate"
+	"git.arilot.com/kuberstack/kuberstack-installer/steps"
+)
+
+// NetworkingProvider is a pod networking (CNI) provider kops sets up
+type NetworkingProvider struct {
+	Name string
+	// NetworkPolicies the provider enforces
+	NetworkPolicies bool
+	// Private the provider works with the private topology
+	Private bool
+}
+
+// DefaultNetworking is the networking the clusters are created with if not set explicitly,
+// it enforces the network policies and works with both topologies
+const DefaultNetworking = "calico"
+
+// NetworkingProviders are the networking providers supported
+var NetworkingProviders = []NetworkingProvider{
+	{Name: "calico", NetworkPolicies: true, Private: true},
+	{Name: "cilium", NetworkPolicies: true, Private: true},
+	{Name: "weave", NetworkPolicies: true, Private: true},
+	{Name: "flannel", NetworkPolicies: false, Private: true},
+	{Name: "amazon-vpc-routed-eni", NetworkPolicies: false, Private: true},
+	// kubenet needs the nodes to be routable from each other directly
+	{Name: "kubenet", NetworkPolicies: false, Private: false},
+}
+
+// GetNetworkingProvider returns the networking provider by name or nil if there is no such provider
+func GetNetworkingProvider(name string) *NetworkingProvider {
+	for i := range NetworkingProviders {
+		if NetworkingProviders[i].Name == name {
+			return &NetworkingProviders[i]
+		}
+	}
+	return nil
+}
+
+// Networking returns the networking provider the cluster is created with
+func Networking(sess *savedstate.State) string {
+	if sess.Networking == "" {
+		return DefaultNetworking
+	}
+	return sess.Networking
+}
+
+// SaveNetworking checks the networking provider against the cluster topology and saves it to the DB
+func SaveNetworking(conn db.Connect, networking string, principal savedstate.Principal) error {
+	state := *principal.Sess
+	state.Networking = networking
+
+	errs := ValidateNetworking(&state)
+	if len(errs) > 0 {
+		return errs
+	}
+
+	principal.Sess.Networking = networking
+
+	return conn.SaveState(principal.ID, principal.Sess)
+}
+
+// ValidateNetworking checks the networking provider is supported with the cluster topology
+func ValidateNetworking(sess *savedstate.State) steps.FieldErrors {
+	var errs steps.FieldErrors
+
+	networking := Networking(sess)
+
+	provider := GetNetworkingProvider(networking)
+	switch {
+	case provider == nil:
+		errs.Add("networking", steps.CodeUnsupported, "Networking provider is not supported: %q", networking)
+	case !provider.Private && sess.Topology() == savedstate.TopologyPrivate:
+		errs.Add("networking", steps.CodeConflict, "Networking provider %q does not support the private topology", networking)
+	}
+
+	return errs
+}
@@ -0,0 +1,92 @@
+package network
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+
+	"git.arilot.com/kuberstack/kuberstack-installer/db"
+	"git.arilot.com/kuberstack/kuberstack-installer/savedstate"
+	"git.arilot.com/kuberstack/kuberstack-installer/steps"
+)
+
+// Save checks the existing VPC and subnets selected and saves them to the DB,
+// empty VPC ID makes kops create a new VPC
+func Save(
+	conn db.Connect,
+	vpcID string,
+	subnetIDs []string,
+	principal savedstate.Principal,
+) error {
+	if vpcID == "" {
+		principal.Sess.Network = nil
+		return conn.SaveState(principal.ID, principal.Sess)
+	}
+
+	var errs steps.FieldErrors
+
+	if len(subnetIDs) == 0 {
+		errs.Add("network.subnets", steps.CodeRequired, "Subnets not set")
+		return errs
+	}
+
+	sess, err := steps.AwsSession(principal.Sess.AccessKey, principal.Sess.SecretKey, principal.Sess.Region)
+	if err != nil {
+		return err
+	}
+	svc := ec2.New(sess)
+
+	vpcs, err := svc.DescribeVpcs(
+		&ec2.DescribeVpcsInput{
+			Filters: []*ec2.Filter{{Name: aws.String("vpc-id"), Values: aws.StringSlice([]string{vpcID})}},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if len(vpcs.Vpcs) == 0 {
+		errs.Add("network.vpcId", steps.CodeInvalid, "No VPC found in region %q: %q", principal.Sess.Region, vpcID)
+		return errs
+	}
+
+	existing, err := getSubnets(svc, vpcID)
+	if err != nil {
+		return err
+	}
+	byID := make(map[string]Subnet, len(existing))
+	for _, subnet := range existing {
+		byID[subnet.ID] = subnet
+	}
+
+	network := &savedstate.NetworkParams{
+		VPCID: vpcID,
+		CIDR:  aws.StringValue(vpcs.Vpcs[0].CidrBlock),
+	}
+
+	for _, id := range subnetIDs {
+		subnet, ok := byID[id]
+		switch {
+		case !ok:
+			errs.Add("network.subnets", steps.CodeInvalid, "Subnet %q does not belong to VPC %q", id, vpcID)
+			continue
+		case !subnet.Public:
+			errs.Add("network.subnets", steps.CodeUnsupported, "Subnet %q is not routed to an internet gateway, the public topology requires public subnets", id)
+		}
+
+		network.Subnets = append(network.Subnets, savedstate.SubnetParams{ID: subnet.ID, Zone: subnet.Zone, CIDR: subnet.CIDR})
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	state := *principal.Sess
+	state.Network = network
+
+	errs = Validate(&state)
+	if len(errs) > 0 {
+		return errs
+	}
+
+	principal.Sess.Network = network
+
+	return conn.SaveState(principal.ID, principal.Sess)
+}
@@ -0,0 +1,114 @@
+package network
+
+import (
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+
+	"git.arilot.com/kuberstack/kuberstack-installer/savedstate"
+	"git.arilot.com/kuberstack/kuberstack-installer/steps"
+)
+
+// tags kops sets on the cluster instances
+const (
+	clusterTag       = "KubernetesCluster"
+	instanceGroupTag = "kops.k8s.io/instancegroup"
+	masterRoleTag    = "k8s.io/role/master"
+)
+
+// SSHInstance is a public cluster instance reachable by SSH
+type SSHInstance struct {
+	Group   string
+	Master  bool
+	Address string
+}
+
+// SSHHost returns the bastion host name of the private cluster. The public cluster has no single
+// SSH host (the API one is a load balancer for several masters), its instances are connected to
+// directly by the addresses SSHInstances returns, so empty string is returned for it.
+func SSHHost(sess *savedstate.State) string {
+	if sess.Bastion != nil {
+		return BastionName(sess)
+	}
+	return ""
+}
+
+// SSHInstances returns the running instances of the public cluster with their public addresses,
+// the masters go first. Nothing is returned for the private cluster: it is reachable through the bastion only.
+func SSHInstances(principal savedstate.Principal) ([]SSHInstance, error) {
+	if principal.Sess.Bastion != nil {
+		return nil, nil
+	}
+
+	sess, err := steps.AwsSession(principal.Sess.AccessKey, principal.Sess.SecretKey, principal.Sess.Region)
+	if err != nil {
+		return nil, err
+	}
+
+	var instances []SSHInstance
+	err = ec2.New(sess).DescribeInstancesPages(
+		&ec2.DescribeInstancesInput{
+			Filters: []*ec2.Filter{
+				{Name: aws.String("tag:" + clusterTag), Values: aws.StringSlice([]string{principal.Sess.ClusterName()})},
+				{Name: aws.String("instance-state-name"), Values: aws.StringSlice([]string{ec2.InstanceStateNameRunning})},
+			},
+		},
+		func(page *ec2.DescribeInstancesOutput, lastPage bool) bool {
+			for _, reservation := range page.Reservations {
+				for _, instance := range reservation.Instances {
+					address := aws.StringValue(instance.PublicDnsName)
+					if address == "" {
+						address = aws.StringValue(instance.PublicIpAddress)
+					}
+					if address == "" {
+						continue
+					}
+
+					instances = append(
+						instances,
+						SSHInstance{
+							Group:   tagValue(instance.Tags, instanceGroupTag),
+							Master:  hasTag(instance.Tags, masterRoleTag),
+							Address: address,
+						},
+					)
+				}
+			}
+			return true
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(instances, func(i, j int) bool {
+		if instances[i].Master != instances[j].Master {
+			return instances[i].Master
+		}
+		if instances[i].Group != instances[j].Group {
+			return instances[i].Group < instances[j].Group
+		}
+		return instances[i].Address < instances[j].Address
+	})
+
+	return instances, nil
+}
+
+func tagValue(tags []*ec2.Tag, key string) string {
+	for _, tag := range tags {
+		if aws.StringValue(tag.Key) == key {
+			return aws.StringValue(tag.Value)
+		}
+	}
+	return ""
+}
+
+func hasTag(tags []*ec2.Tag, key string) bool {
+	for _, tag := range tags {
+		if aws.StringValue(tag.Key) == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+package network
+
+import (
+	"math"
+	"net"
+	"sort"
+
+	"git.arilot.com/kuberstack/kuberstack-installer/savedstate"
+	"git.arilot.com/kuberstack/kuberstack-installer/steps"
+)
+
+// kops reserves the range for the pods and services addresses (--non-masquerade-cidr default)
+var nonMasqueradeCIDR = mustParseCIDR("100.64.0.0/10")
+
+// AWS reserves the first four and the last address of every subnet
+const reservedSubnetIPs = 5
+
+// Validate checks the existing VPC and subnets against the cluster groups:
+// there is a single subnet in every zone used and it is large enough for the instances planned there
+func Validate(sess *savedstate.State) steps.FieldErrors {
+	var errs steps.FieldErrors
+
+	network := sess.Network
+	if network == nil {
+		return errs
+	}
+
+	_, vpcNet, err := net.ParseCIDR(network.CIDR)
+	switch {
+	case err != nil:
+		errs.Add("network.vpcId", steps.CodeInvalid, "VPC CIDR is not valid: %q", network.CIDR)
+	case overlaps(vpcNet, nonMasqueradeCIDR):
+		errs.Add("network.vpcId", steps.CodeConflict, "VPC CIDR %s overlaps the range %s kops reserves for pods and services", network.CIDR, nonMasqueradeCIDR)
+	}
+
+	subnets := make(map[string]savedstate.SubnetParams, len(network.Subnets))
+	for _, subnet := range network.Subnets {
+		if _, ok := subnets[subnet.Zone]; ok {
+			errs.Add("network.subnets", steps.CodeConflict, "Several subnets selected in zone %q", subnet.Zone)
+		}
+		subnets[subnet.Zone] = subnet
+	}
+
+	planned := plannedIPs(sess)
+	for _, zone := range sortedZones(planned) {
+		subnet, ok := subnets[zone]
+		if !ok {
+			errs.Add("network.subnets", steps.CodeRequired, "No subnet selected in zone %q", zone)
+			continue
+		}
+
+		_, subnetNet, err := net.ParseCIDR(subnet.CIDR)
+		if err != nil {
+			errs.Add("network.subnets", steps.CodeInvalid, "Subnet %q CIDR is not valid: %q", subnet.ID, subnet.CIDR)
+			continue
+		}
+		if capacity(subnetNet) < planned[zone] {
+			errs.Add(
+				"network.subnets",
+				steps.CodeOutOfRange,
+				"Subnet %q (%s) is too small for %d instances planned in zone %q",
+				subnet.ID,
+				subnet.CIDR,
+				planned[zone],
+				zone,
+			)
+		}
+	}
+
+	return errs
+}
+
+// plannedIPs returns the max number of instances per zone,
+// the groups are spread over their zones evenly
+func plannedIPs(sess *savedstate.State) map[string]int64 {
+	res := make(map[string]int64)
+
+	add := func(group savedstate.NodesParams) {
+		if len(group.Zones) == 0 {
+			return
+		}
+		size := group.MaxSize
+		if size < group.Quantity {
+			size = group.Quantity
+		}
+		perZone := int64(math.Ceil(float64(size) / float64(len(group.Zones))))
+		for _, zone := range group.Zones {
+			res[zone] += perZone
+		}
+	}
+
+	add(sess.Master)
+	add(sess.Nodes)
+	for _, group := range sess.Groups {
+		add(group.NodesParams)
+	}
+
+	return res
+}
+
+func capacity(ipNet *net.IPNet) int64 {
+	ones, bits := ipNet.Mask.Size()
+	return int64(1)<<uint(bits-ones) - reservedSubnetIPs
+}
+
+func overlaps(a *net.IPNet, b *net.IPNet) bool {
+	return a.Contains(b.IP) || b.Contains(a.IP)
+}
+
+func sortedZones(m map[string]int64) []string {
+	zones := make([]string, 0, len(m))
+	for zone := range m {
+		zones = append(zones, zone)
+	}
+	sort.Strings(zones)
+	return zones
+}
+
+func mustParseCIDR(cidr string) *net.IPNet {
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		panic(err)
+	}
+	return ipNet
+}
@@ -0,0 +1,89 @@
+package nodes
+
+import (
+	"sort"
+
+	"git.arilot.com/kuberstack/kuberstack-installer/predefined"
+)
+
+// TypeFilter narrows the instance types catalog down,
+// zero values mean no limit
+type TypeFilter struct {
+	MinCPU    int64
+	MinMemory float64
+	// Role is RoleMaster or RoleNode, empty for any role
+	Role string
+	// Zones the types should be offered in, all of them
+	Zones []string
+	// Architecture is ArchX86 or ArchARM64, empty for any one
+	Architecture string
+}
+
+// CatalogEntry is an instance type spec with its hourly on-demand price,
+// zero price means the type is not priced in the region
+type CatalogEntry struct {
+	predefined.InstanceTypeSpec
+	Price float64
+	// Zones the type is offered in, nil if the availability is unknown
+	Zones []string
+}
+
+// GetCatalog returns the types possible to be used with create call matching the filter,
+// the cheapest types go first, types not priced in the region go last
+func GetCatalog(region string, offerings Offerings, filter TypeFilter) []CatalogEntry {
+	var prices map[string]float64
+	if regionPrices := predefined.GetRegionPrices(region); regionPrices != nil {
+		prices = regionPrices.Instances
+	}
+
+	res := make([]CatalogEntry, 0, len(handledTypes))
+	for _, t := range handledTypes {
+		entry := CatalogEntry{
+			InstanceTypeSpec: predefined.InstanceTypeSpec{Name: t, Architecture: Architecture(t)},
+			Price:            prices[t],
+			Zones:            offerings.Zones(t),
+		}
+		if spec := predefined.GetInstanceType(t); spec != nil {
+			entry.InstanceTypeSpec = *spec
+		}
+
+		if filter.match(entry.InstanceTypeSpec, offerings) {
+			res = append(res, entry)
+		}
+	}
+
+	sort.SliceStable(
+		res,
+		func(i, j int) bool {
+			switch {
+			case res[i].Price == 0 || res[j].Price == 0:
+				return res[i].Price != 0
+			case res[i].Price != res[j].Price:
+				return res[i].Price < res[j].Price
+			default:
+				return res[i].Name < res[j].Name
+			}
+		},
+	)
+
+	return res
+}
+
+func (filter TypeFilter) match(spec predefined.InstanceTypeSpec, offerings Offerings) bool {
+	if spec.VCPU < filter.MinCPU || spec.Memory < filter.MinMemory {
+		return false
+	}
+	if filter.Architecture != "" && spec.Architecture != filter.Architecture {
+		return false
+	}
+	for _, zone := range filter.Zones {
+		if !offerings.Offered(spec.Name, zone) {
+			return false
+		}
+	}
+	if filter.Role != "" && !checkRoleType(filter.Role, spec.Name) {
+		return false
+	}
+	// GPUs are of no use for the control plane
+	return filter.Role != RoleMaster || spec.GPUs == 0
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"text/template"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+type family struct {
+	Name          string   `yaml:"name"`
+	Architecture  string   `yaml:"architecture"`
+	ExcludedSizes []string `yaml:"excludedSizes"`
+}
+
+func main() {
+	flag.Parse()
+
+	tmpl := template.Must(template.ParseFiles(*Flags.DstTemplate))
+
+	src, err := ioutil.ReadFile(*Flags.SrcFile)
+	panicOnErr(err)
+
+	var params struct {
+		SrcFile    string            `yaml:"-"`
+		DstPackage string            `yaml:"-"`
+		Images     map[string]string `yaml:"images"`
+		Families   []family          `yaml:"families"`
+	}
+	panicOnErr(yaml.UnmarshalStrict(src, &params))
+
+	params.SrcFile = *Flags.SrcFile
+	params.DstPackage = *Flags.DstPackage
+
+	file, err := os.Create(*Flags.DstFile)
+	panicOnErr(err)
+	defer func() {
+		panicOnErr(file.Close())
+	}()
+
+	panicOnErr(tmpl.Execute(file, params))
+}
+
+func panicOnErr(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Flags is a struct for command line flags ready to be utilized by flag.Parse()
+var Flags = struct {
+	SrcFile     *string
+	DstFile     *string
+	DstTemplate *string
+	DstPackage  *string
+}{
+	flag.String("SrcFile", "", "Source data file name"),
+	flag.String("DstFile", "", "Destination file name"),
+	flag.String("DstTemplate", "", "Destination template file name"),
+	flag.String("DstPackage", "main", "Destination package name"),
+}
@@ -0,0 +1,146 @@
+package nodes
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"git.arilot.com/kuberstack/kuberstack-installer/protocol/gen/models"
+	"git.arilot.com/kuberstack/kuberstack-installer/savedstate"
+	"git.arilot.com/kuberstack/kuberstack-installer/steps"
+)
+
+const (
+	maxLabelNameLength   = 63
+	maxLabelPrefixLength = 253
+)
+
+var (
+	groupNameRegexp   = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+	labelPrefixRegexp = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+	labelNameRegexp   = regexp.MustCompile(`^[A-Za-z0-9]([-A-Za-z0-9_.]*[A-Za-z0-9])?$`)
+	taintRegexp       = regexp.MustCompile(`^([^=:]+)(=([^=:]*))?:([A-Za-z]+)$`)
+)
+
+// kops names the default groups "nodes" and "master-<zone>" and the bastion one "bastions"
+var reservedGroupNames = []string{
+	"nodes",
+	"bastions",
+}
+
+const reservedGroupPrefix = "master-"
+
+var taintEffects = []string{
+	"NoSchedule",
+	"PreferNoSchedule",
+	"NoExecute",
+}
+
+// ValidateGroups checks all the additional instance groups
+// and makes sure their names are unique
+func ValidateGroups(groups []savedstate.InstanceGroup, region string) steps.FieldErrors {
+	var errs steps.FieldErrors
+
+	seen := make(map[string]bool, len(groups))
+	for _, group := range groups {
+		if seen[group.Name] {
+			errs.Add("groups."+group.Name, steps.CodeConflict, "Instance group listed twice: %q", group.Name)
+		}
+		seen[group.Name] = true
+
+		errs = append(errs, ValidateInstanceGroup(group, region)...)
+	}
+
+	return errs
+}
+
+// ValidateInstanceGroup checks an additional instance group
+func ValidateInstanceGroup(group savedstate.InstanceGroup, region string) steps.FieldErrors {
+	var errs steps.FieldErrors
+
+	field := "groups." + group.Name
+
+	switch {
+	case group.Name == "":
+		field = "groups"
+		errs.Add(field+".name", steps.CodeRequired, "Instance group name not set")
+	case len(group.Name) > maxLabelNameLength || !groupNameRegexp.MatchString(group.Name):
+		errs.Add(field+".name", steps.CodeInvalid, "Instance group name should be a valid DNS label: %q", group.Name)
+	case steps.StrInSlice(group.Name, reservedGroupNames) || strings.HasPrefix(group.Name, reservedGroupPrefix):
+		errs.Add(field+".name", steps.CodeConflict, "Instance group name is reserved: %q", group.Name)
+	}
+
+	errs = append(errs, ValidateGroup(field, RoleNode, group.NodesParams, region)...)
+
+	for _, key := range sortedKeys(group.Labels) {
+		if !isLabelKey(key) {
+			errs.Add(field+".labels", steps.CodeInvalid, "Label key is not valid: %q", key)
+		}
+		if value := group.Labels[key]; value != "" && (len(value) > maxLabelNameLength || !labelNameRegexp.MatchString(value)) {
+			errs.Add(field+".labels", steps.CodeInvalid, "Label value is not valid: %q", value)
+		}
+	}
+
+	for _, taint := range group.Taints {
+		match := taintRegexp.FindStringSubmatch(taint)
+		switch {
+		case match == nil:
+			errs.Add(field+".taints", steps.CodeInvalid, "Taint should be in the key=value:Effect format: %q", taint)
+		case !isLabelKey(match[1]):
+			errs.Add(field+".taints", steps.CodeInvalid, "Taint key is not valid: %q", match[1])
+		case !steps.StrInSlice(match[4], taintEffects):
+			errs.Add(field+".taints", steps.CodeUnsupported, "Taint effect should be one of %v: %q", taintEffects, taint)
+		}
+	}
+
+	return errs
+}
+
+// ToInstanceGroup converts the API request to the instance group to be saved
+func ToInstanceGroup(req models.InstanceGroup) savedstate.InstanceGroup {
+	return savedstate.InstanceGroup{
+		Name: req.Name,
+		NodesParams: savedstate.NodesParams{
+			Type:        req.InstanceType,
+			Quantity:    req.Instances,
+			MaxSize:     req.MaxInstances,
+			Zones:       req.Zones,
+			StorageSize: req.StorageSize,
+			StorageType: req.StorageType,
+			Spot:        toSpotParams(req.Spot),
+
+			StorageIOPS:       req.StorageIops,
+			StorageThroughput: req.StorageThroughput,
+			StorageEncrypted:  req.StorageEncrypted,
+			StorageKMSKey:     req.StorageKmsKey,
+			Volumes:           toVolumeParams(req.Volumes),
+
+			Image: req.Image,
+		},
+		Labels: req.Labels,
+		Taints: req.Taints,
+	}
+}
+
+// isLabelKey checks the Kubernetes label key, it is an optional DNS subdomain prefix
+// followed by a slash and a name
+func isLabelKey(key string) bool {
+	name := key
+	if i := strings.LastIndex(key, "/"); i >= 0 {
+		prefix := key[:i]
+		name = key[i+1:]
+		if len(prefix) > maxLabelPrefixLength || !labelPrefixRegexp.MatchString(prefix) {
+			return false
+		}
+	}
+	return len(name) <= maxLabelNameLength && labelNameRegexp.MatchString(name)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,200 @@
+package nodes
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+
+	"git.arilot.com/kuberstack/kuberstack-installer/predefined"
+	"git.arilot.com/kuberstack/kuberstack-installer/savedstate"
+	"git.arilot.com/kuberstack/kuberstack-installer/steps"
+)
+
+// ImagesConfig are the machine images options
+type ImagesConfig struct {
+	HardenedOwner string `long:"hardenedImageOwner" description:"AWS account the hardened base image is shared from, the image is used by default if set" env:"HARDENEDIMAGEOWNER"`
+}
+
+var amiIDRegexp = regexp.MustCompile(`^ami-[0-9a-f]{8,17}$`)
+
+// kops launches the instances with EBS root volumes only
+const rootDeviceTypeEBS = "ebs"
+
+// errCodeInvalidAMIID is the prefix of the DescribeImages error codes
+// for the image ID not found, malformed or unavailable
+const errCodeInvalidAMIID = "InvalidAMIID."
+
+// Image is a machine image found in the region
+type Image struct {
+	ID             string
+	Name           string
+	Architecture   string
+	RootDeviceType string
+	// Curated is the curated image ID, empty if the image is not a curated one
+	Curated  string
+	Hardened bool
+}
+
+// GroupImage returns the image to run the group with,
+// the architecture one is used if no image set, empty string means the kops default image
+func GroupImage(group savedstate.NodesParams) string {
+	if group.Image != "" {
+		return group.Image
+	}
+	return ArchImage(group.Type)
+}
+
+// ResolveImage looks the group image up in the region and replaces it with the image ID.
+// The image requested is an AMI ID, an owner/name pattern or a curated image ID,
+// the hardened image is used if nothing requested and the image is configured,
+// kops picks its default image otherwise.
+// steps.FieldErrors returned if the image does not match the group instance type.
+func ResolveImage(
+	sess *session.Session,
+	field string,
+	group *savedstate.NodesParams,
+	config ImagesConfig,
+) error {
+	var errs steps.FieldErrors
+
+	requested := group.Image
+	if requested == "" {
+		hardened := predefined.GetHardenedImage()
+		if hardened == nil || config.HardenedOwner == "" {
+			return nil
+		}
+		requested = hardened.ID
+	}
+
+	image, err := findImage(ec2.New(sess), field+".image", requested, Architecture(group.Type), config)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case image == nil:
+		errs.Add(field+".image", steps.CodeInvalid, "No %s image found: %q", Architecture(group.Type), requested)
+	case image.Architecture != Architecture(group.Type):
+		errs.Add(field+".image", steps.CodeConflict, "Image %q is of %s architecture, instance type %q is of %s one", requested, image.Architecture, group.Type, Architecture(group.Type))
+	case image.RootDeviceType != rootDeviceTypeEBS:
+		errs.Add(field+".image", steps.CodeUnsupported, "Image %q root device is of %s type, EBS-backed images are supported only", requested, image.RootDeviceType)
+	default:
+		group.Image = image.ID
+	}
+
+	return errs.Err()
+}
+
+// GetImages returns the curated images available in the region for the architecture
+func GetImages(
+	region string,
+	arch string,
+	principal savedstate.Principal,
+	config ImagesConfig,
+) ([]Image, error) {
+	sess, err := steps.AwsSession(principal.Sess.AccessKey, principal.Sess.SecretKey, region)
+	if err != nil {
+		return nil, err
+	}
+	svc := ec2.New(sess)
+
+	res := make([]Image, 0, len(predefined.Images))
+	for _, curated := range predefined.Images {
+		if curated.Hardened && config.HardenedOwner == "" {
+			continue
+		}
+
+		image, err := findImage(svc, "image", curated.ID, arch, config)
+		if err != nil {
+			return nil, err
+		}
+		if image != nil {
+			res = append(res, *image)
+		}
+	}
+
+	return res, nil
+}
+
+// findImage returns the latest available image matching the image requested
+// or nil if there is no such image, the images looked up by name are EBS-backed ones of the architecture
+func findImage(svc *ec2.EC2, field string, requested string, arch string, config ImagesConfig) (*Image, error) {
+	var errs steps.FieldErrors
+
+	input := &ec2.DescribeImagesInput{
+		Filters: []*ec2.Filter{
+			{Name: aws.String("state"), Values: aws.StringSlice([]string{ec2.ImageStateAvailable})},
+		},
+	}
+	byName := func(pattern string) []*ec2.Filter {
+		return append(
+			input.Filters,
+			&ec2.Filter{Name: aws.String("name"), Values: aws.StringSlice([]string{pattern})},
+			&ec2.Filter{Name: aws.String("architecture"), Values: aws.StringSlice([]string{arch})},
+			&ec2.Filter{Name: aws.String("root-device-type"), Values: aws.StringSlice([]string{rootDeviceTypeEBS})},
+		)
+	}
+
+	curated := predefined.GetImage(requested)
+
+	switch {
+	case amiIDRegexp.MatchString(requested):
+		// the image is not filtered to report the mismatch
+		input.ImageIds = aws.StringSlice([]string{requested})
+	case curated != nil:
+		owner := curated.Owner
+		if curated.Hardened {
+			owner = config.HardenedOwner
+		}
+		if owner == "" {
+			errs.Add(field, steps.CodeUnsupported, "Image is not configured: %q", requested)
+			return nil, errs
+		}
+		input.Owners = aws.StringSlice([]string{owner})
+		input.Filters = byName(curated.NamePattern)
+	case strings.Contains(requested, "/"):
+		parts := strings.SplitN(requested, "/", 2)
+		input.Owners = aws.StringSlice([]string{parts[0]})
+		input.Filters = byName(parts[1])
+	default:
+		errs.Add(field, steps.CodeInvalid, "Image should be an AMI ID, an owner/name pattern or a curated image ID: %q", requested)
+		return nil, errs
+	}
+
+	res, err := svc.DescribeImages(input)
+	if awsErr, ok := err.(awserr.Error); ok && strings.HasPrefix(awsErr.Code(), errCodeInvalidAMIID) {
+		errs.Add(field, steps.CodeInvalid, "No such image: %q", requested)
+		return nil, errs
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var latest *ec2.Image
+	for _, image := range res.Images {
+		// the creation dates are in the same ISO 8601 format, so they are compared as strings
+		if latest == nil || aws.StringValue(image.CreationDate) > aws.StringValue(latest.CreationDate) {
+			latest = image
+		}
+	}
+	if latest == nil {
+		return nil, nil
+	}
+
+	image := &Image{
+		ID:             aws.StringValue(latest.ImageId),
+		Name:           aws.StringValue(latest.Name),
+		Architecture:   aws.StringValue(latest.Architecture),
+		RootDeviceType: aws.StringValue(latest.RootDeviceType),
+	}
+	if curated != nil {
+		image.Curated = curated.ID
+		image.Hardened = curated.Hardened
+	}
+
+	return image, nil
+}
@@ -4,20 +4,206 @@ package nodes
 
 import srcPkg "github.com/aws/aws-sdk-go/service/ec2"
 
-// InstanceTypes an array of declarations '^InstanceType.+' extracted from "github.com/aws/aws-sdk-go/service/ec2"
+// InstanceTypes an array of declarations '^InstanceType[A-Z][a-z]*[0-9].+' extracted from "github.com/aws/aws-sdk-go/service/ec2"
 var InstanceTypes = []string{
-	srcPkg.InstanceTypeT1Micro,
-	srcPkg.InstanceTypeT2Nano,
-	srcPkg.InstanceTypeT2Micro,
-	srcPkg.InstanceTypeT2Small,
-	srcPkg.InstanceTypeT2Medium,
-	srcPkg.InstanceTypeT2Large,
-	srcPkg.InstanceTypeT2Xlarge,
-	srcPkg.InstanceTypeT22xlarge,
+	srcPkg.InstanceTypeA1Medium,
+	srcPkg.InstanceTypeA1Large,
+	srcPkg.InstanceTypeA1Xlarge,
+	srcPkg.InstanceTypeA12xlarge,
+	srcPkg.InstanceTypeA14xlarge,
+	srcPkg.InstanceTypeA1Metal,
+	srcPkg.InstanceTypeC1Medium,
+	srcPkg.InstanceTypeC1Xlarge,
+	srcPkg.InstanceTypeC3Large,
+	srcPkg.InstanceTypeC3Xlarge,
+	srcPkg.InstanceTypeC32xlarge,
+	srcPkg.InstanceTypeC34xlarge,
+	srcPkg.InstanceTypeC38xlarge,
+	srcPkg.InstanceTypeC4Large,
+	srcPkg.InstanceTypeC4Xlarge,
+	srcPkg.InstanceTypeC42xlarge,
+	srcPkg.InstanceTypeC44xlarge,
+	srcPkg.InstanceTypeC48xlarge,
+	srcPkg.InstanceTypeC5Large,
+	srcPkg.InstanceTypeC5Xlarge,
+	srcPkg.InstanceTypeC52xlarge,
+	srcPkg.InstanceTypeC54xlarge,
+	srcPkg.InstanceTypeC59xlarge,
+	srcPkg.InstanceTypeC512xlarge,
+	srcPkg.InstanceTypeC518xlarge,
+	srcPkg.InstanceTypeC524xlarge,
+	srcPkg.InstanceTypeC5Metal,
+	srcPkg.InstanceTypeC5aLarge,
+	srcPkg.InstanceTypeC5aXlarge,
+	srcPkg.InstanceTypeC5a2xlarge,
+	srcPkg.InstanceTypeC5a4xlarge,
+	srcPkg.InstanceTypeC5a8xlarge,
+	srcPkg.InstanceTypeC5a12xlarge,
+	srcPkg.InstanceTypeC5a16xlarge,
+	srcPkg.InstanceTypeC5a24xlarge,
+	srcPkg.InstanceTypeC5adLarge,
+	srcPkg.InstanceTypeC5adXlarge,
+	srcPkg.InstanceTypeC5ad2xlarge,
+	srcPkg.InstanceTypeC5ad4xlarge,
+	srcPkg.InstanceTypeC5ad8xlarge,
+	srcPkg.InstanceTypeC5ad12xlarge,
+	srcPkg.InstanceTypeC5ad16xlarge,
+	srcPkg.InstanceTypeC5ad24xlarge,
+	srcPkg.InstanceTypeC5dLarge,
+	srcPkg.InstanceTypeC5dXlarge,
+	srcPkg.InstanceTypeC5d2xlarge,
+	srcPkg.InstanceTypeC5d4xlarge,
+	srcPkg.InstanceTypeC5d9xlarge,
+	srcPkg.InstanceTypeC5d12xlarge,
+	srcPkg.InstanceTypeC5d18xlarge,
+	srcPkg.InstanceTypeC5d24xlarge,
+	srcPkg.InstanceTypeC5dMetal,
+	srcPkg.InstanceTypeC5nLarge,
+	srcPkg.InstanceTypeC5nXlarge,
+	srcPkg.InstanceTypeC5n2xlarge,
+	srcPkg.InstanceTypeC5n4xlarge,
+	srcPkg.InstanceTypeC5n9xlarge,
+	srcPkg.InstanceTypeC5n18xlarge,
+	srcPkg.InstanceTypeC5nMetal,
+	srcPkg.InstanceTypeC6gMedium,
+	srcPkg.InstanceTypeC6gLarge,
+	srcPkg.InstanceTypeC6gXlarge,
+	srcPkg.InstanceTypeC6g2xlarge,
+	srcPkg.InstanceTypeC6g4xlarge,
+	srcPkg.InstanceTypeC6g8xlarge,
+	srcPkg.InstanceTypeC6g12xlarge,
+	srcPkg.InstanceTypeC6g16xlarge,
+	srcPkg.InstanceTypeC6gMetal,
+	srcPkg.InstanceTypeC6gdMedium,
+	srcPkg.InstanceTypeC6gdLarge,
+	srcPkg.InstanceTypeC6gdXlarge,
+	srcPkg.InstanceTypeC6gd2xlarge,
+	srcPkg.InstanceTypeC6gd4xlarge,
+	srcPkg.InstanceTypeC6gd8xlarge,
+	srcPkg.InstanceTypeC6gd12xlarge,
+	srcPkg.InstanceTypeC6gd16xlarge,
+	srcPkg.InstanceTypeC6gdMetal,
+	srcPkg.InstanceTypeC6gnMedium,
+	srcPkg.InstanceTypeC6gnLarge,
+	srcPkg.InstanceTypeC6gnXlarge,
+	srcPkg.InstanceTypeC6gn2xlarge,
+	srcPkg.InstanceTypeC6gn4xlarge,
+	srcPkg.InstanceTypeC6gn8xlarge,
+	srcPkg.InstanceTypeC6gn12xlarge,
+	srcPkg.InstanceTypeC6gn16xlarge,
+	srcPkg.InstanceTypeC6iLarge,
+	srcPkg.InstanceTypeC6iXlarge,
+	srcPkg.InstanceTypeC6i2xlarge,
+	srcPkg.InstanceTypeC6i4xlarge,
+	srcPkg.InstanceTypeC6i8xlarge,
+	srcPkg.InstanceTypeC6i12xlarge,
+	srcPkg.InstanceTypeC6i16xlarge,
+	srcPkg.InstanceTypeC6i24xlarge,
+	srcPkg.InstanceTypeC6i32xlarge,
+	srcPkg.InstanceTypeC6iMetal,
+	srcPkg.InstanceTypeCc14xlarge,
+	srcPkg.InstanceTypeCc28xlarge,
+	srcPkg.InstanceTypeCg14xlarge,
+	srcPkg.InstanceTypeCr18xlarge,
+	srcPkg.InstanceTypeD2Xlarge,
+	srcPkg.InstanceTypeD22xlarge,
+	srcPkg.InstanceTypeD24xlarge,
+	srcPkg.InstanceTypeD28xlarge,
+	srcPkg.InstanceTypeD3Xlarge,
+	srcPkg.InstanceTypeD32xlarge,
+	srcPkg.InstanceTypeD34xlarge,
+	srcPkg.InstanceTypeD38xlarge,
+	srcPkg.InstanceTypeD3enXlarge,
+	srcPkg.InstanceTypeD3en2xlarge,
+	srcPkg.InstanceTypeD3en4xlarge,
+	srcPkg.InstanceTypeD3en6xlarge,
+	srcPkg.InstanceTypeD3en8xlarge,
+	srcPkg.InstanceTypeD3en12xlarge,
+	srcPkg.InstanceTypeDl124xlarge,
+	srcPkg.InstanceTypeF12xlarge,
+	srcPkg.InstanceTypeF14xlarge,
+	srcPkg.InstanceTypeF116xlarge,
+	srcPkg.InstanceTypeG22xlarge,
+	srcPkg.InstanceTypeG28xlarge,
+	srcPkg.InstanceTypeG34xlarge,
+	srcPkg.InstanceTypeG38xlarge,
+	srcPkg.InstanceTypeG316xlarge,
+	srcPkg.InstanceTypeG3sXlarge,
+	srcPkg.InstanceTypeG4adXlarge,
+	srcPkg.InstanceTypeG4ad2xlarge,
+	srcPkg.InstanceTypeG4ad4xlarge,
+	srcPkg.InstanceTypeG4ad8xlarge,
+	srcPkg.InstanceTypeG4ad16xlarge,
+	srcPkg.InstanceTypeG4dnXlarge,
+	srcPkg.InstanceTypeG4dn2xlarge,
+	srcPkg.InstanceTypeG4dn4xlarge,
+	srcPkg.InstanceTypeG4dn8xlarge,
+	srcPkg.InstanceTypeG4dn12xlarge,
+	srcPkg.InstanceTypeG4dn16xlarge,
+	srcPkg.InstanceTypeG4dnMetal,
+	srcPkg.InstanceTypeG5Xlarge,
+	srcPkg.InstanceTypeG52xlarge,
+	srcPkg.InstanceTypeG54xlarge,
+	srcPkg.InstanceTypeG58xlarge,
+	srcPkg.InstanceTypeG512xlarge,
+	srcPkg.InstanceTypeG516xlarge,
+	srcPkg.InstanceTypeG524xlarge,
+	srcPkg.InstanceTypeG548xlarge,
+	srcPkg.InstanceTypeG5gXlarge,
+	srcPkg.InstanceTypeG5g2xlarge,
+	srcPkg.InstanceTypeG5g4xlarge,
+	srcPkg.InstanceTypeG5g8xlarge,
+	srcPkg.InstanceTypeG5g16xlarge,
+	srcPkg.InstanceTypeG5gMetal,
+	srcPkg.InstanceTypeHi14xlarge,
+	srcPkg.InstanceTypeHpc6a48xlarge,
+	srcPkg.InstanceTypeHs18xlarge,
+	srcPkg.InstanceTypeH12xlarge,
+	srcPkg.InstanceTypeH14xlarge,
+	srcPkg.InstanceTypeH18xlarge,
+	srcPkg.InstanceTypeH116xlarge,
+	srcPkg.InstanceTypeI2Xlarge,
+	srcPkg.InstanceTypeI22xlarge,
+	srcPkg.InstanceTypeI24xlarge,
+	srcPkg.InstanceTypeI28xlarge,
+	srcPkg.InstanceTypeI3Large,
+	srcPkg.InstanceTypeI3Xlarge,
+	srcPkg.InstanceTypeI32xlarge,
+	srcPkg.InstanceTypeI34xlarge,
+	srcPkg.InstanceTypeI38xlarge,
+	srcPkg.InstanceTypeI316xlarge,
+	srcPkg.InstanceTypeI3Metal,
+	srcPkg.InstanceTypeI3enLarge,
+	srcPkg.InstanceTypeI3enXlarge,
+	srcPkg.InstanceTypeI3en2xlarge,
+	srcPkg.InstanceTypeI3en3xlarge,
+	srcPkg.InstanceTypeI3en6xlarge,
+	srcPkg.InstanceTypeI3en12xlarge,
+	srcPkg.InstanceTypeI3en24xlarge,
+	srcPkg.InstanceTypeI3enMetal,
+	srcPkg.InstanceTypeIm4gnLarge,
+	srcPkg.InstanceTypeIm4gnXlarge,
+	srcPkg.InstanceTypeIm4gn2xlarge,
+	srcPkg.InstanceTypeIm4gn4xlarge,
+	srcPkg.InstanceTypeIm4gn8xlarge,
+	srcPkg.InstanceTypeIm4gn16xlarge,
+	srcPkg.InstanceTypeInf1Xlarge,
+	srcPkg.InstanceTypeInf12xlarge,
+	srcPkg.InstanceTypeInf16xlarge,
+	srcPkg.InstanceTypeInf124xlarge,
+	srcPkg.InstanceTypeIs4genMedium,
+	srcPkg.InstanceTypeIs4genLarge,
+	srcPkg.InstanceTypeIs4genXlarge,
+	srcPkg.InstanceTypeIs4gen2xlarge,
+	srcPkg.InstanceTypeIs4gen4xlarge,
+	srcPkg.InstanceTypeIs4gen8xlarge,
 	srcPkg.InstanceTypeM1Small,
 	srcPkg.InstanceTypeM1Medium,
 	srcPkg.InstanceTypeM1Large,
 	srcPkg.InstanceTypeM1Xlarge,
+	srcPkg.InstanceTypeM2Xlarge,
+	srcPkg.InstanceTypeM22xlarge,
+	srcPkg.InstanceTypeM24xlarge,
 	srcPkg.InstanceTypeM3Medium,
 	srcPkg.InstanceTypeM3Large,
 	srcPkg.InstanceTypeM3Xlarge,
@@ -28,10 +214,112 @@ var InstanceTypes = []string{
 	srcPkg.InstanceTypeM44xlarge,
 	srcPkg.InstanceTypeM410xlarge,
 	srcPkg.InstanceTypeM416xlarge,
-	srcPkg.InstanceTypeM2Xlarge,
-	srcPkg.InstanceTypeM22xlarge,
-	srcPkg.InstanceTypeM24xlarge,
-	srcPkg.InstanceTypeCr18xlarge,
+	srcPkg.InstanceTypeM5Large,
+	srcPkg.InstanceTypeM5Xlarge,
+	srcPkg.InstanceTypeM52xlarge,
+	srcPkg.InstanceTypeM54xlarge,
+	srcPkg.InstanceTypeM58xlarge,
+	srcPkg.InstanceTypeM512xlarge,
+	srcPkg.InstanceTypeM516xlarge,
+	srcPkg.InstanceTypeM524xlarge,
+	srcPkg.InstanceTypeM5Metal,
+	srcPkg.InstanceTypeM5aLarge,
+	srcPkg.InstanceTypeM5aXlarge,
+	srcPkg.InstanceTypeM5a2xlarge,
+	srcPkg.InstanceTypeM5a4xlarge,
+	srcPkg.InstanceTypeM5a8xlarge,
+	srcPkg.InstanceTypeM5a12xlarge,
+	srcPkg.InstanceTypeM5a16xlarge,
+	srcPkg.InstanceTypeM5a24xlarge,
+	srcPkg.InstanceTypeM5adLarge,
+	srcPkg.InstanceTypeM5adXlarge,
+	srcPkg.InstanceTypeM5ad2xlarge,
+	srcPkg.InstanceTypeM5ad4xlarge,
+	srcPkg.InstanceTypeM5ad8xlarge,
+	srcPkg.InstanceTypeM5ad12xlarge,
+	srcPkg.InstanceTypeM5ad16xlarge,
+	srcPkg.InstanceTypeM5ad24xlarge,
+	srcPkg.InstanceTypeM5dLarge,
+	srcPkg.InstanceTypeM5dXlarge,
+	srcPkg.InstanceTypeM5d2xlarge,
+	srcPkg.InstanceTypeM5d4xlarge,
+	srcPkg.InstanceTypeM5d8xlarge,
+	srcPkg.InstanceTypeM5d12xlarge,
+	srcPkg.InstanceTypeM5d16xlarge,
+	srcPkg.InstanceTypeM5d24xlarge,
+	srcPkg.InstanceTypeM5dMetal,
+	srcPkg.InstanceTypeM5dnLarge,
+	srcPkg.InstanceTypeM5dnXlarge,
+	srcPkg.InstanceTypeM5dn2xlarge,
+	srcPkg.InstanceTypeM5dn4xlarge,
+	srcPkg.InstanceTypeM5dn8xlarge,
+	srcPkg.InstanceTypeM5dn12xlarge,
+	srcPkg.InstanceTypeM5dn16xlarge,
+	srcPkg.InstanceTypeM5dn24xlarge,
+	srcPkg.InstanceTypeM5dnMetal,
+	srcPkg.InstanceTypeM5nLarge,
+	srcPkg.InstanceTypeM5nXlarge,
+	srcPkg.InstanceTypeM5n2xlarge,
+	srcPkg.InstanceTypeM5n4xlarge,
+	srcPkg.InstanceTypeM5n8xlarge,
+	srcPkg.InstanceTypeM5n12xlarge,
+	srcPkg.InstanceTypeM5n16xlarge,
+	srcPkg.InstanceTypeM5n24xlarge,
+	srcPkg.InstanceTypeM5nMetal,
+	srcPkg.InstanceTypeM5znLarge,
+	srcPkg.InstanceTypeM5znXlarge,
+	srcPkg.InstanceTypeM5zn2xlarge,
+	srcPkg.InstanceTypeM5zn3xlarge,
+	srcPkg.InstanceTypeM5zn6xlarge,
+	srcPkg.InstanceTypeM5zn12xlarge,
+	srcPkg.InstanceTypeM5znMetal,
+	srcPkg.InstanceTypeM6aLarge,
+	srcPkg.InstanceTypeM6aXlarge,
+	srcPkg.InstanceTypeM6a2xlarge,
+	srcPkg.InstanceTypeM6a4xlarge,
+	srcPkg.InstanceTypeM6a8xlarge,
+	srcPkg.InstanceTypeM6a12xlarge,
+	srcPkg.InstanceTypeM6a16xlarge,
+	srcPkg.InstanceTypeM6a24xlarge,
+	srcPkg.InstanceTypeM6a32xlarge,
+	srcPkg.InstanceTypeM6a48xlarge,
+	srcPkg.InstanceTypeM6gMetal,
+	srcPkg.InstanceTypeM6gMedium,
+	srcPkg.InstanceTypeM6gLarge,
+	srcPkg.InstanceTypeM6gXlarge,
+	srcPkg.InstanceTypeM6g2xlarge,
+	srcPkg.InstanceTypeM6g4xlarge,
+	srcPkg.InstanceTypeM6g8xlarge,
+	srcPkg.InstanceTypeM6g12xlarge,
+	srcPkg.InstanceTypeM6g16xlarge,
+	srcPkg.InstanceTypeM6gdMetal,
+	srcPkg.InstanceTypeM6gdMedium,
+	srcPkg.InstanceTypeM6gdLarge,
+	srcPkg.InstanceTypeM6gdXlarge,
+	srcPkg.InstanceTypeM6gd2xlarge,
+	srcPkg.InstanceTypeM6gd4xlarge,
+	srcPkg.InstanceTypeM6gd8xlarge,
+	srcPkg.InstanceTypeM6gd12xlarge,
+	srcPkg.InstanceTypeM6gd16xlarge,
+	srcPkg.InstanceTypeM6iLarge,
+	srcPkg.InstanceTypeM6iXlarge,
+	srcPkg.InstanceTypeM6i2xlarge,
+	srcPkg.InstanceTypeM6i4xlarge,
+	srcPkg.InstanceTypeM6i8xlarge,
+	srcPkg.InstanceTypeM6i12xlarge,
+	srcPkg.InstanceTypeM6i16xlarge,
+	srcPkg.InstanceTypeM6i24xlarge,
+	srcPkg.InstanceTypeM6i32xlarge,
+	srcPkg.InstanceTypeM6iMetal,
+	srcPkg.InstanceTypeMac1Metal,
+	srcPkg.InstanceTypeP2Xlarge,
+	srcPkg.InstanceTypeP28xlarge,
+	srcPkg.InstanceTypeP216xlarge,
+	srcPkg.InstanceTypeP32xlarge,
+	srcPkg.InstanceTypeP38xlarge,
+	srcPkg.InstanceTypeP316xlarge,
+	srcPkg.InstanceTypeP3dn24xlarge,
+	srcPkg.InstanceTypeP4d24xlarge,
 	srcPkg.InstanceTypeR3Large,
 	srcPkg.InstanceTypeR3Xlarge,
 	srcPkg.InstanceTypeR32xlarge,
@@ -43,47 +331,186 @@ var InstanceTypes = []string{
 	srcPkg.InstanceTypeR44xlarge,
 	srcPkg.InstanceTypeR48xlarge,
 	srcPkg.InstanceTypeR416xlarge,
+	srcPkg.InstanceTypeR5Large,
+	srcPkg.InstanceTypeR5Xlarge,
+	srcPkg.InstanceTypeR52xlarge,
+	srcPkg.InstanceTypeR54xlarge,
+	srcPkg.InstanceTypeR58xlarge,
+	srcPkg.InstanceTypeR512xlarge,
+	srcPkg.InstanceTypeR516xlarge,
+	srcPkg.InstanceTypeR524xlarge,
+	srcPkg.InstanceTypeR5Metal,
+	srcPkg.InstanceTypeR5aLarge,
+	srcPkg.InstanceTypeR5aXlarge,
+	srcPkg.InstanceTypeR5a2xlarge,
+	srcPkg.InstanceTypeR5a4xlarge,
+	srcPkg.InstanceTypeR5a8xlarge,
+	srcPkg.InstanceTypeR5a12xlarge,
+	srcPkg.InstanceTypeR5a16xlarge,
+	srcPkg.InstanceTypeR5a24xlarge,
+	srcPkg.InstanceTypeR5adLarge,
+	srcPkg.InstanceTypeR5adXlarge,
+	srcPkg.InstanceTypeR5ad2xlarge,
+	srcPkg.InstanceTypeR5ad4xlarge,
+	srcPkg.InstanceTypeR5ad8xlarge,
+	srcPkg.InstanceTypeR5ad12xlarge,
+	srcPkg.InstanceTypeR5ad16xlarge,
+	srcPkg.InstanceTypeR5ad24xlarge,
+	srcPkg.InstanceTypeR5bLarge,
+	srcPkg.InstanceTypeR5bXlarge,
+	srcPkg.InstanceTypeR5b2xlarge,
+	srcPkg.InstanceTypeR5b4xlarge,
+	srcPkg.InstanceTypeR5b8xlarge,
+	srcPkg.InstanceTypeR5b12xlarge,
+	srcPkg.InstanceTypeR5b16xlarge,
+	srcPkg.InstanceTypeR5b24xlarge,
+	srcPkg.InstanceTypeR5bMetal,
+	srcPkg.InstanceTypeR5dLarge,
+	srcPkg.InstanceTypeR5dXlarge,
+	srcPkg.InstanceTypeR5d2xlarge,
+	srcPkg.InstanceTypeR5d4xlarge,
+	srcPkg.InstanceTypeR5d8xlarge,
+	srcPkg.InstanceTypeR5d12xlarge,
+	srcPkg.InstanceTypeR5d16xlarge,
+	srcPkg.InstanceTypeR5d24xlarge,
+	srcPkg.InstanceTypeR5dMetal,
+	srcPkg.InstanceTypeR5dnLarge,
+	srcPkg.InstanceTypeR5dnXlarge,
+	srcPkg.InstanceTypeR5dn2xlarge,
+	srcPkg.InstanceTypeR5dn4xlarge,
+	srcPkg.InstanceTypeR5dn8xlarge,
+	srcPkg.InstanceTypeR5dn12xlarge,
+	srcPkg.InstanceTypeR5dn16xlarge,
+	srcPkg.InstanceTypeR5dn24xlarge,
+	srcPkg.InstanceTypeR5dnMetal,
+	srcPkg.InstanceTypeR5nLarge,
+	srcPkg.InstanceTypeR5nXlarge,
+	srcPkg.InstanceTypeR5n2xlarge,
+	srcPkg.InstanceTypeR5n4xlarge,
+	srcPkg.InstanceTypeR5n8xlarge,
+	srcPkg.InstanceTypeR5n12xlarge,
+	srcPkg.InstanceTypeR5n16xlarge,
+	srcPkg.InstanceTypeR5n24xlarge,
+	srcPkg.InstanceTypeR5nMetal,
+	srcPkg.InstanceTypeR6gMedium,
+	srcPkg.InstanceTypeR6gLarge,
+	srcPkg.InstanceTypeR6gXlarge,
+	srcPkg.InstanceTypeR6g2xlarge,
+	srcPkg.InstanceTypeR6g4xlarge,
+	srcPkg.InstanceTypeR6g8xlarge,
+	srcPkg.InstanceTypeR6g12xlarge,
+	srcPkg.InstanceTypeR6g16xlarge,
+	srcPkg.InstanceTypeR6gMetal,
+	srcPkg.InstanceTypeR6gdMedium,
+	srcPkg.InstanceTypeR6gdLarge,
+	srcPkg.InstanceTypeR6gdXlarge,
+	srcPkg.InstanceTypeR6gd2xlarge,
+	srcPkg.InstanceTypeR6gd4xlarge,
+	srcPkg.InstanceTypeR6gd8xlarge,
+	srcPkg.InstanceTypeR6gd12xlarge,
+	srcPkg.InstanceTypeR6gd16xlarge,
+	srcPkg.InstanceTypeR6gdMetal,
+	srcPkg.InstanceTypeR6iLarge,
+	srcPkg.InstanceTypeR6iXlarge,
+	srcPkg.InstanceTypeR6i2xlarge,
+	srcPkg.InstanceTypeR6i4xlarge,
+	srcPkg.InstanceTypeR6i8xlarge,
+	srcPkg.InstanceTypeR6i12xlarge,
+	srcPkg.InstanceTypeR6i16xlarge,
+	srcPkg.InstanceTypeR6i24xlarge,
+	srcPkg.InstanceTypeR6i32xlarge,
+	srcPkg.InstanceTypeR6iMetal,
+	srcPkg.InstanceTypeT1Micro,
+	srcPkg.InstanceTypeT2Nano,
+	srcPkg.InstanceTypeT2Micro,
+	srcPkg.InstanceTypeT2Small,
+	srcPkg.InstanceTypeT2Medium,
+	srcPkg.InstanceTypeT2Large,
+	srcPkg.InstanceTypeT2Xlarge,
+	srcPkg.InstanceTypeT22xlarge,
+	srcPkg.InstanceTypeT3Nano,
+	srcPkg.InstanceTypeT3Micro,
+	srcPkg.InstanceTypeT3Small,
+	srcPkg.InstanceTypeT3Medium,
+	srcPkg.InstanceTypeT3Large,
+	srcPkg.InstanceTypeT3Xlarge,
+	srcPkg.InstanceTypeT32xlarge,
+	srcPkg.InstanceTypeT3aNano,
+	srcPkg.InstanceTypeT3aMicro,
+	srcPkg.InstanceTypeT3aSmall,
+	srcPkg.InstanceTypeT3aMedium,
+	srcPkg.InstanceTypeT3aLarge,
+	srcPkg.InstanceTypeT3aXlarge,
+	srcPkg.InstanceTypeT3a2xlarge,
+	srcPkg.InstanceTypeT4gNano,
+	srcPkg.InstanceTypeT4gMicro,
+	srcPkg.InstanceTypeT4gSmall,
+	srcPkg.InstanceTypeT4gMedium,
+	srcPkg.InstanceTypeT4gLarge,
+	srcPkg.InstanceTypeT4gXlarge,
+	srcPkg.InstanceTypeT4g2xlarge,
+	srcPkg.InstanceTypeU6tb156xlarge,
+	srcPkg.InstanceTypeU6tb1112xlarge,
+	srcPkg.InstanceTypeU9tb1112xlarge,
+	srcPkg.InstanceTypeU12tb1112xlarge,
+	srcPkg.InstanceTypeU6tb1Metal,
+	srcPkg.InstanceTypeU9tb1Metal,
+	srcPkg.InstanceTypeU12tb1Metal,
+	srcPkg.InstanceTypeU18tb1Metal,
+	srcPkg.InstanceTypeU24tb1Metal,
+	srcPkg.InstanceTypeVt13xlarge,
+	srcPkg.InstanceTypeVt16xlarge,
+	srcPkg.InstanceTypeVt124xlarge,
 	srcPkg.InstanceTypeX116xlarge,
 	srcPkg.InstanceTypeX132xlarge,
-	srcPkg.InstanceTypeI2Xlarge,
-	srcPkg.InstanceTypeI22xlarge,
-	srcPkg.InstanceTypeI24xlarge,
-	srcPkg.InstanceTypeI28xlarge,
-	srcPkg.InstanceTypeI3Large,
-	srcPkg.InstanceTypeI3Xlarge,
-	srcPkg.InstanceTypeI32xlarge,
-	srcPkg.InstanceTypeI34xlarge,
-	srcPkg.InstanceTypeI38xlarge,
-	srcPkg.InstanceTypeI316xlarge,
-	srcPkg.InstanceTypeHi14xlarge,
-	srcPkg.InstanceTypeHs18xlarge,
-	srcPkg.InstanceTypeC1Medium,
-	srcPkg.InstanceTypeC1Xlarge,
-	srcPkg.InstanceTypeC3Large,
-	srcPkg.InstanceTypeC3Xlarge,
-	srcPkg.InstanceTypeC32xlarge,
-	srcPkg.InstanceTypeC34xlarge,
-	srcPkg.InstanceTypeC38xlarge,
-	srcPkg.InstanceTypeC4Large,
-	srcPkg.InstanceTypeC4Xlarge,
-	srcPkg.InstanceTypeC42xlarge,
-	srcPkg.InstanceTypeC44xlarge,
-	srcPkg.InstanceTypeC48xlarge,
-	srcPkg.InstanceTypeCc14xlarge,
-	srcPkg.InstanceTypeCc28xlarge,
-	srcPkg.InstanceTypeG22xlarge,
-	srcPkg.InstanceTypeG28xlarge,
-	srcPkg.InstanceTypeG34xlarge,
-	srcPkg.InstanceTypeG38xlarge,
-	srcPkg.InstanceTypeG316xlarge,
-	srcPkg.InstanceTypeCg14xlarge,
-	srcPkg.InstanceTypeP2Xlarge,
-	srcPkg.InstanceTypeP28xlarge,
-	srcPkg.InstanceTypeP216xlarge,
-	srcPkg.InstanceTypeD2Xlarge,
-	srcPkg.InstanceTypeD22xlarge,
-	srcPkg.InstanceTypeD24xlarge,
-	srcPkg.InstanceTypeD28xlarge,
-	srcPkg.InstanceTypeF12xlarge,
-	srcPkg.InstanceTypeF116xlarge,
+	srcPkg.InstanceTypeX1eXlarge,
+	srcPkg.InstanceTypeX1e2xlarge,
+	srcPkg.InstanceTypeX1e4xlarge,
+	srcPkg.InstanceTypeX1e8xlarge,
+	srcPkg.InstanceTypeX1e16xlarge,
+	srcPkg.InstanceTypeX1e32xlarge,
+	srcPkg.InstanceTypeX2iezn2xlarge,
+	srcPkg.InstanceTypeX2iezn4xlarge,
+	srcPkg.InstanceTypeX2iezn6xlarge,
+	srcPkg.InstanceTypeX2iezn8xlarge,
+	srcPkg.InstanceTypeX2iezn12xlarge,
+	srcPkg.InstanceTypeX2ieznMetal,
+	srcPkg.InstanceTypeX2gdMedium,
+	srcPkg.InstanceTypeX2gdLarge,
+	srcPkg.InstanceTypeX2gdXlarge,
+	srcPkg.InstanceTypeX2gd2xlarge,
+	srcPkg.InstanceTypeX2gd4xlarge,
+	srcPkg.InstanceTypeX2gd8xlarge,
+	srcPkg.InstanceTypeX2gd12xlarge,
+	srcPkg.InstanceTypeX2gd16xlarge,
+	srcPkg.InstanceTypeX2gdMetal,
+	srcPkg.InstanceTypeZ1dLarge,
+	srcPkg.InstanceTypeZ1dXlarge,
+	srcPkg.InstanceTypeZ1d2xlarge,
+	srcPkg.InstanceTypeZ1d3xlarge,
+	srcPkg.InstanceTypeZ1d6xlarge,
+	srcPkg.InstanceTypeZ1d12xlarge,
+	srcPkg.InstanceTypeZ1dMetal,
+	srcPkg.InstanceTypeX2idn16xlarge,
+	srcPkg.InstanceTypeX2idn24xlarge,
+	srcPkg.InstanceTypeX2idn32xlarge,
+	srcPkg.InstanceTypeX2iednXlarge,
+	srcPkg.InstanceTypeX2iedn2xlarge,
+	srcPkg.InstanceTypeX2iedn4xlarge,
+	srcPkg.InstanceTypeX2iedn8xlarge,
+	srcPkg.InstanceTypeX2iedn16xlarge,
+	srcPkg.InstanceTypeX2iedn24xlarge,
+	srcPkg.InstanceTypeX2iedn32xlarge,
+	srcPkg.InstanceTypeC6aLarge,
+	srcPkg.InstanceTypeC6aXlarge,
+	srcPkg.InstanceTypeC6a2xlarge,
+	srcPkg.InstanceTypeC6a4xlarge,
+	srcPkg.InstanceTypeC6a8xlarge,
+	srcPkg.InstanceTypeC6a12xlarge,
+	srcPkg.InstanceTypeC6a16xlarge,
+	srcPkg.InstanceTypeC6a24xlarge,
+	srcPkg.InstanceTypeC6a32xlarge,
+	srcPkg.InstanceTypeC6a48xlarge,
+	srcPkg.InstanceTypeC6aMetal,
+	srcPkg.InstanceTypeM6aMetal,
 }
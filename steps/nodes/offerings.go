@@ -0,0 +1,145 @@
+package nodes
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+
+	"git.arilot.com/kuberstack/kuberstack-installer/savedstate"
+	"git.arilot.com/kuberstack/kuberstack-installer/steps"
+)
+
+// offerings are refreshed rarely as AWS adds types to zones once in a while
+const offeringsTTL = time.Hour
+
+// Offerings holds the instance types offered per availability zone of a region,
+// nil Offerings means the availability is unknown
+type Offerings map[string]map[string]bool
+
+type offeringsEntry struct {
+	offerings Offerings
+	fetched   time.Time
+}
+
+// offeringsKey is the offerings cache key. Zone names are mapped to the physical zones
+// per AWS account, so the offerings are cached per account (access key) and region.
+type offeringsKey struct {
+	accessKey string
+	region    string
+}
+
+var offeringsCache struct {
+	entries map[offeringsKey]offeringsEntry
+	sync.RWMutex
+}
+
+func init() {
+	offeringsCache.entries = make(map[offeringsKey]offeringsEntry, 4)
+}
+
+// GetOfferings returns the instance types offered in the region zones,
+// the result is cached per account and region
+func GetOfferings(
+	region string,
+	principal savedstate.Principal,
+) (Offerings, error) {
+	key := offeringsKey{accessKey: principal.Sess.AccessKey, region: region}
+
+	offeringsCache.RLock()
+	entry, ok := offeringsCache.entries[key]
+	offeringsCache.RUnlock()
+
+	if ok && time.Since(entry.fetched) < offeringsTTL {
+		return entry.offerings, nil
+	}
+
+	sess, err := steps.AwsSession(principal.Sess.AccessKey, principal.Sess.SecretKey, region)
+	if err != nil {
+		return nil, err
+	}
+
+	offerings := make(Offerings)
+	err = ec2.New(sess).DescribeInstanceTypeOfferingsPages(
+		&ec2.DescribeInstanceTypeOfferingsInput{
+			LocationType: aws.String(ec2.LocationTypeAvailabilityZone),
+		},
+		func(page *ec2.DescribeInstanceTypeOfferingsOutput, lastPage bool) bool {
+			for _, offering := range page.InstanceTypeOfferings {
+				zone := aws.StringValue(offering.Location)
+				if offerings[zone] == nil {
+					offerings[zone] = make(map[string]bool)
+				}
+				offerings[zone][aws.StringValue(offering.InstanceType)] = true
+			}
+			return true
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	offeringsCache.Lock()
+	defer offeringsCache.Unlock()
+
+	offeringsCache.entries[key] = offeringsEntry{offerings: offerings, fetched: time.Now()}
+
+	return offerings, nil
+}
+
+// Offered checks if the type can be launched in the zone,
+// everything is offered if the availability is unknown
+func (offerings Offerings) Offered(t string, zone string) bool {
+	return offerings == nil || offerings[zone][t]
+}
+
+// Zones returns the sorted list of zones the type is offered in
+func (offerings Offerings) Zones(t string) []string {
+	if offerings == nil {
+		return nil
+	}
+
+	zones := make([]string, 0, len(offerings))
+	for zone, types := range offerings {
+		if types[t] {
+			zones = append(zones, zone)
+		}
+	}
+	sort.Strings(zones)
+
+	return zones
+}
+
+// Types returns the handled types offered in the zone
+func (offerings Offerings) Types(zone string) []string {
+	res := make([]string, 0, len(handledTypes))
+	for _, t := range handledTypes {
+		if offerings.Offered(t, zone) {
+			res = append(res, t)
+		}
+	}
+	return res
+}
+
+// ValidateOfferings checks the group types, including the spot ones,
+// are offered in all the group zones
+func ValidateOfferings(field string, group savedstate.NodesParams, offerings Offerings) steps.FieldErrors {
+	var errs steps.FieldErrors
+
+	types := []string{group.Type}
+	if group.Spot != nil {
+		types = append(types, group.Spot.Types...)
+	}
+
+	for _, zone := range group.Zones {
+		for _, t := range types {
+			if t != "" && !offerings.Offered(t, zone) {
+				errs.Add(field+".zones", steps.CodeUnsupported, "Instance type %q is not offered in zone %q", t, zone)
+			}
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,88 @@
+package nodes
+
+import (
+	"strings"
+
+	"git.arilot.com/kuberstack/kuberstack-installer/predefined"
+	"git.arilot.com/kuberstack/kuberstack-installer/savedstate"
+	"git.arilot.com/kuberstack/kuberstack-installer/steps"
+)
+
+// ValidateClusterType checks both groups of nodes against the limits of the cluster type chosen.
+// No limits are applied if the type is not chosen yet.
+func ValidateClusterType(
+	typeID int64,
+	master savedstate.NodesParams,
+	nodes savedstate.NodesParams,
+) steps.FieldErrors {
+	clusterType := predefined.GetType(typeID)
+	if clusterType == nil {
+		return nil
+	}
+
+	errs := validatePreset("master", clusterType, clusterType.Masters, master)
+	return append(errs, validatePreset("nodes", clusterType, clusterType.Nodes, nodes)...)
+}
+
+func validatePreset(
+	field string,
+	clusterType *predefined.ClusterType,
+	preset predefined.GroupPreset,
+	group savedstate.NodesParams,
+) steps.FieldErrors {
+	var errs steps.FieldErrors
+
+	if preset.Max > 0 && group.MaxSize > preset.Max {
+		errs.Add(
+			field+".maxInstances",
+			steps.CodeOutOfRange,
+			"%s cluster allows up to %d instances, got %d",
+			clusterType.Name,
+			preset.Max,
+			group.MaxSize,
+		)
+	}
+
+	if group.Quantity < preset.Min || (preset.Max > 0 && group.Quantity > preset.Max) {
+		errs.Add(
+			field+".instances",
+			steps.CodeOutOfRange,
+			"%s cluster allows %d to %d instances, got %d",
+			clusterType.Name,
+			preset.Min,
+			preset.Max,
+			group.Quantity,
+		)
+	}
+
+	if group.Type != "" &&
+		len(clusterType.InstanceFamilies) > 0 &&
+		!steps.StrInSlice(instanceFamily(group.Type), clusterType.InstanceFamilies) {
+		errs.Add(
+			field+".instanceType",
+			steps.CodeUnsupported,
+			"%s cluster allows %v instance families only, got %q",
+			clusterType.Name,
+			clusterType.InstanceFamilies,
+			group.Type,
+		)
+	}
+
+	if clusterType.MultiZone && countDistinct(group.Zones) < 2 {
+		errs.Add(field+".zones", steps.CodeInvalid, "%s cluster requires several zones", clusterType.Name)
+	}
+
+	return errs
+}
+
+func instanceFamily(t string) string {
+	return strings.SplitN(t, ".", 2)[0]
+}
+
+func countDistinct(strs []string) int {
+	seen := make(map[string]bool, len(strs))
+	for _, str := range strs {
+		seen[str] = true
+	}
+	return len(seen)
+}
@@ -1,11 +1,10 @@
 package nodes
 
 import (
-	"fmt"
-
 	"git.arilot.com/kuberstack/kuberstack-installer/db"
 	"git.arilot.com/kuberstack/kuberstack-installer/protocol/gen/models"
 	"git.arilot.com/kuberstack/kuberstack-installer/savedstate"
+	"git.arilot.com/kuberstack/kuberstack-installer/steps"
 )
 
 // MinVolumeSize is a minimum volume size for any instance
@@ -17,41 +16,70 @@ func Save(
 	master models.NodesRequest,
 	nodes models.NodesRequest,
 	principal savedstate.Principal,
+	imagesConfig ImagesConfig,
 ) error {
-	if !checkMachineType(master.InstanceType) {
-		return fmt.Errorf("Instance type not handled: %q", master.InstanceType)
+	masterParams := toNodesParams(master)
+	nodesParams := toNodesParams(nodes)
+
+	errs := ValidateGroup("master", RoleMaster, masterParams, principal.Sess.Region)
+	errs = append(errs, ValidateGroup("nodes", RoleNode, nodesParams, principal.Sess.Region)...)
+	errs = append(errs, ValidateClusterType(principal.Sess.Type, masterParams, nodesParams)...)
+	if len(errs) > 0 {
+		return errs
 	}
-	if !checkMachineType(nodes.InstanceType) {
-		return fmt.Errorf("Instance type not handled: %q", nodes.InstanceType)
+
+	offerings, err := GetOfferings(principal.Sess.Region, principal)
+	if err != nil {
+		return err
 	}
-	if master.StorageSize < MinVolumeSize || nodes.StorageSize < MinVolumeSize {
-		return fmt.Errorf("Minimum volume size is %d", MinVolumeSize)
+
+	errs = ValidateOfferings("master", masterParams, offerings)
+	errs = append(errs, ValidateOfferings("nodes", nodesParams, offerings)...)
+	if len(errs) > 0 {
+		return errs
 	}
-	if master.Instances > 0 &&
-		len(master.Zones) > 0 &&
-		master.Instances != int64(len(master.Zones)) {
-		return fmt.Errorf(
-			"specified %d master zones, but also requested %d masters. If specifying both, the count should match",
-			len(master.Zones),
-			master.Instances,
-		)
+
+	sess, err := steps.AwsSession(principal.Sess.AccessKey, principal.Sess.SecretKey, principal.Sess.Region)
+	if err != nil {
+		return err
 	}
 
-	principal.Sess.Master.Type = master.InstanceType
-	principal.Sess.Master.Quantity = master.Instances
-	principal.Sess.Master.Zones = master.Zones
-	principal.Sess.Master.StorageSize = master.StorageSize
-	principal.Sess.Master.StorageType = master.StorageType
+	err = ResolveImage(sess, "master", &masterParams, imagesConfig)
+	if err != nil {
+		return err
+	}
 
-	principal.Sess.Nodes.Type = nodes.InstanceType
-	principal.Sess.Nodes.Quantity = nodes.Instances
-	principal.Sess.Nodes.Zones = nodes.Zones
-	principal.Sess.Nodes.StorageSize = nodes.StorageSize
-	principal.Sess.Nodes.StorageType = nodes.StorageType
+	err = ResolveImage(sess, "nodes", &nodesParams, imagesConfig)
+	if err != nil {
+		return err
+	}
+
+	principal.Sess.Master = masterParams
+	principal.Sess.Nodes = nodesParams
 
 	return conn.SaveState(principal.ID, principal.Sess)
 }
 
+func toNodesParams(req models.NodesRequest) savedstate.NodesParams {
+	return savedstate.NodesParams{
+		Type:        req.InstanceType,
+		Quantity:    req.Instances,
+		MaxSize:     req.MaxInstances,
+		Zones:       req.Zones,
+		StorageSize: req.StorageSize,
+		StorageType: req.StorageType,
+		Spot:        toSpotParams(req.Spot),
+
+		StorageIOPS:       req.StorageIops,
+		StorageThroughput: req.StorageThroughput,
+		StorageEncrypted:  req.StorageEncrypted,
+		StorageKMSKey:     req.StorageKmsKey,
+		Volumes:           toVolumeParams(req.Volumes),
+
+		Image: req.Image,
+	}
+}
+
 func checkMachineType(t string) bool {
 	for _, mType := range handledTypes {
 		if t == mType {
@@ -0,0 +1,52 @@
+package nodes
+
+import (
+	"git.arilot.com/kuberstack/kuberstack-installer/protocol/gen/models"
+	"git.arilot.com/kuberstack/kuberstack-installer/savedstate"
+	"git.arilot.com/kuberstack/kuberstack-installer/steps"
+)
+
+func validateSpot(field string, group savedstate.NodesParams) steps.FieldErrors {
+	var errs steps.FieldErrors
+
+	spot := group.Spot
+
+	if spot.MaxPrice < 0 {
+		errs.Add(field+".maxPrice", steps.CodeOutOfRange, "Max spot price should not be negative")
+	}
+
+	maxSize := group.MaxSize
+	if maxSize < group.Quantity {
+		maxSize = group.Quantity
+	}
+	if spot.OnDemandBase < 0 || spot.OnDemandBase > maxSize {
+		errs.Add(field+".onDemandBase", steps.CodeOutOfRange, "Number of on-demand instances should be between 0 and %d", maxSize)
+	}
+
+	seen := make(map[string]bool, len(spot.Types))
+	for _, t := range spot.Types {
+		switch {
+		case t == group.Type || seen[t]:
+			errs.Add(field+".instanceTypes", steps.CodeConflict, "Instance type listed twice: %q", t)
+		case !checkMachineType(t):
+			errs.Add(field+".instanceTypes", steps.CodeUnsupported, "Instance type not handled: %q", t)
+		case Architecture(t) != Architecture(group.Type):
+			errs.Add(field+".instanceTypes", steps.CodeConflict, "Instance type %q is not of %s architecture", t, Architecture(group.Type))
+		}
+		seen[t] = true
+	}
+
+	return errs
+}
+
+func toSpotParams(req *models.SpotOptions) *savedstate.SpotParams {
+	if req == nil {
+		return nil
+	}
+
+	return &savedstate.SpotParams{
+		MaxPrice:     req.MaxPrice,
+		Types:        req.InstanceTypes,
+		OnDemandBase: req.OnDemandBase,
+	}
+}
@@ -3,54 +3,49 @@ package nodes
 import (
 	"strings"
 
-	"github.com/aws/aws-sdk-go/service/ec2"
+	"git.arilot.com/kuberstack/kuberstack-installer/steps"
 )
 
-//go:generate go run declextractor/main.go -DeclName=^InstanceType.+ -SrcPackage=github.com/aws/aws-sdk-go/service/ec2 -DstPackage=nodes -VarName=InstanceTypes -VarType=[]string -DstTemplate=types.tmpl -DstFile=instancetypes.go
+//go:generate go run declextractor/main.go -DeclName=^InstanceType[A-Z][a-z]*[0-9].+ -SrcPackage=github.com/aws/aws-sdk-go/service/ec2 -DstPackage=nodes -VarName=InstanceTypes -VarType=[]string -DstTemplate=types.tmpl -DstFile=instancetypes.go
 //go:generate go run declextractor/main.go -DeclName=^VolumeType.+   -SrcPackage=github.com/aws/aws-sdk-go/service/ec2 -DstPackage=nodes -VarName=VolumeTypes   -VarType=[]string -DstTemplate=types.tmpl -DstFile=volumetypes.go
+//go:generate go run familiesgen/main.go -SrcFile=families.yml -DstPackage=nodes -DstTemplate=families.tmpl -DstFile=families.go
 
-// obtained from kops/upup/pkg/fi/cloudup/awsup/machine_types.go
-var kopsHandledPrefixes = []string{
-	"t2.",
-	"m3.",
-	"m4.",
-	"c3.",
-	"c4.",
-	"cc2.",
-	"cg1.",
-	"cr1.",
-	"d2.",
-	"g2.",
-	"hi1.",
-	"i2.",
-	"i3.",
-	"r3.",
-	"x1.",
-	"r4.",
-	"p2.",
-}
+// CPU architectures of the instance types
+const (
+	ArchX86   = "x86_64"
+	ArchARM64 = "arm64"
+)
 
-var kopsProhibitedInstTypes = []string{
-	ec2.InstanceTypeT2Nano,
-	ec2.InstanceTypeT2Micro,
-	ec2.InstanceTypeT2Small,
+// Family is an instance family kops is able to handle
+type Family struct {
+	Name         string
+	Architecture string
+	// ExcludedSizes are the sizes of the family not to be used with kops
+	ExcludedSizes []string
 }
 
 var handledTypes []string
 
+// BastionTypes are the small instance types not handled for the cluster nodes
+// but good enough for the bastion host
+var BastionTypes = []string{
+	"t3.micro",
+	"t3.small",
+	"t3a.micro",
+	"t3a.small",
+	"t4g.micro",
+	"t4g.small",
+}
+
 func init() {
 	handledTypes = make([]string, 0, len(InstanceTypes))
 	for _, mType := range InstanceTypes {
-		if checkStrInSlice(mType, kopsProhibitedInstTypes) {
+		family := getFamily(mType)
+		if family == nil || steps.StrInSlice(instanceSize(mType), family.ExcludedSizes) {
 			continue
 		}
 
-		for _, prefix := range kopsHandledPrefixes {
-			if strings.HasPrefix(mType, prefix) {
-				handledTypes = append(handledTypes, mType)
-				continue
-			}
-		}
+		handledTypes = append(handledTypes, mType)
 	}
 }
 
@@ -59,16 +54,45 @@ func GetNodeTypes() []string {
 	return handledTypes
 }
 
+// CheckBastionType checks the instance type is suitable for the bastion host
+func CheckBastionType(t string) bool {
+	return checkMachineType(t) || steps.StrInSlice(t, BastionTypes)
+}
+
+// Architecture returns the CPU architecture of the instance type,
+// x86_64 is assumed for the families unknown
+func Architecture(t string) string {
+	if family := getFamily(t); family != nil {
+		return family.Architecture
+	}
+	return ArchX86
+}
+
+// ArchImage returns the image to run the instance type with,
+// empty string means the kops default image
+func ArchImage(t string) string {
+	return archImages[Architecture(t)]
+}
+
 // GetVolumeTypes returns a list of types possible to be used with create call
 func GetVolumeTypes() []string {
 	return VolumeTypes
 }
 
-func checkStrInSlice(str string, slc []string) bool {
-	for _, chk := range slc {
-		if str == chk {
-			return true
+func getFamily(t string) *Family {
+	name := instanceFamily(t)
+	for i := range handledFamilies {
+		if handledFamilies[i].Name == name {
+			return &handledFamilies[i]
 		}
 	}
-	return false
+	return nil
+}
+
+func instanceSize(t string) string {
+	parts := strings.SplitN(t, ".", 2)
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[1]
 }
@@ -0,0 +1,137 @@
+package nodes
+
+import (
+	"strings"
+
+	"git.arilot.com/kuberstack/kuberstack-installer/savedstate"
+	"git.arilot.com/kuberstack/kuberstack-installer/steps"
+)
+
+// Roles of the instance groups
+const (
+	RoleMaster  = "Master"
+	RoleNode    = "Node"
+	RoleBastion = "Bastion"
+)
+
+// MaxVolumeSize is a maximum volume size (in GB) for any instance
+const MaxVolumeSize = 16384
+
+// sizes too small to run the Kubernetes control plane
+var masterProhibitedSizes = []string{
+	".nano",
+	".micro",
+	".small",
+}
+
+// ValidateGroup checks a group of nodes of the role provided,
+// field is a name of the group to prefix the error fields with
+func ValidateGroup(
+	field string,
+	role string,
+	group savedstate.NodesParams,
+	region string,
+) steps.FieldErrors {
+	var errs steps.FieldErrors
+
+	switch {
+	case group.Type == "":
+		errs.Add(field+".instanceType", steps.CodeRequired, "Instance type not set")
+	case !checkMachineType(group.Type):
+		errs.Add(field+".instanceType", steps.CodeUnsupported, "Instance type not handled: %q", group.Type)
+	case !checkRoleType(role, group.Type):
+		errs.Add(field+".instanceType", steps.CodeUnsupported, "Instance type %q is too small for %s", group.Type, role)
+	}
+
+	switch {
+	case group.Quantity <= 0:
+		errs.Add(field+".instances", steps.CodeRequired, "Number of instances not set")
+	case group.MaxSize != 0 && group.MaxSize < group.Quantity:
+		errs.Add(field+".maxInstances", steps.CodeOutOfRange, "Max number of instances should not be less than %d", group.Quantity)
+	}
+
+	errs = append(errs, validateVolumes(field, group)...)
+
+	errs = append(errs, validateZones(field+".zones", group.Zones, region)...)
+
+	switch {
+	case group.Spot == nil:
+	case role == RoleMaster:
+		errs.Add(field+".spot", steps.CodeUnsupported, "Masters can not run on spot instances")
+	default:
+		errs = append(errs, validateSpot(field+".spot", group)...)
+	}
+
+	if role == RoleMaster && group.MaxSize > group.Quantity {
+		errs.Add(field+".maxInstances", steps.CodeUnsupported, "Masters can not be autoscaled")
+	}
+
+	if role == RoleMaster {
+		errs = append(errs, validateMasters(field, group)...)
+	}
+
+	return errs
+}
+
+func validateZones(field string, zones []string, region string) steps.FieldErrors {
+	var errs steps.FieldErrors
+
+	if len(zones) == 0 {
+		errs.Add(field, steps.CodeRequired, "Zones not set")
+		return errs
+	}
+
+	seen := make(map[string]bool, len(zones))
+	for _, zone := range zones {
+		if seen[zone] {
+			errs.Add(field, steps.CodeConflict, "Zone listed twice: %q", zone)
+		}
+		seen[zone] = true
+
+		// Availability zone name is the region name followed by a single letter
+		if region != "" && (!strings.HasPrefix(zone, region) || len(zone) != len(region)+1) {
+			errs.Add(field, steps.CodeInvalid, "Zone %q does not belong to region %q", zone, region)
+		}
+	}
+
+	return errs
+}
+
+func validateMasters(field string, group savedstate.NodesParams) steps.FieldErrors {
+	var errs steps.FieldErrors
+
+	if group.Quantity > 0 && group.Quantity%2 == 0 {
+		errs.Add(field+".instances", steps.CodeInvalid, "Number of masters should be odd to keep etcd quorum, got %d", group.Quantity)
+	}
+
+	if group.Quantity > 0 &&
+		len(group.Zones) > 0 &&
+		group.Quantity != int64(len(group.Zones)) {
+		errs.Add(
+			field+".zones",
+			steps.CodeConflict,
+			"specified %d master zones, but also requested %d masters. If specifying both, the count should match",
+			len(group.Zones),
+			group.Quantity,
+		)
+	}
+
+	return errs
+}
+
+// CheckRoleType checks the instance type is handled and is suitable for the role of the cluster nodes
+func CheckRoleType(role string, t string) bool {
+	return checkMachineType(t) && checkRoleType(role, t)
+}
+
+func checkRoleType(role string, t string) bool {
+	if role != RoleMaster {
+		return true
+	}
+	for _, size := range masterProhibitedSizes {
+		if strings.HasSuffix(t, size) {
+			return false
+		}
+	}
+	return true
+}
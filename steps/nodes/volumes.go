@@ -0,0 +1,187 @@
+package nodes
+
+import (
+	"fmt"
+	"regexp"
+
+	"git.arilot.com/kuberstack/kuberstack-installer/protocol/gen/models"
+	"git.arilot.com/kuberstack/kuberstack-installer/savedstate"
+	"git.arilot.com/kuberstack/kuberstack-installer/steps"
+)
+
+// DefaultVolumeType is the type of volumes with no type set
+const DefaultVolumeType = "gp2"
+
+// volumeLimits are the EBS limits of a volume type,
+// zero MaxIOPS means IOPS are not provisioned for the type,
+// zero MaxThroughput means throughput is not provisioned for the type
+type volumeLimits struct {
+	MinSize       int64
+	MaxSize       int64
+	MinIOPS       int64
+	MaxIOPS       int64
+	IOPSPerGB     int64
+	IOPSRequired  bool
+	MinThroughput int64
+	MaxThroughput int64
+	// ThroughputPerIOPS is a max throughput (in MiB/s) per 1000 IOPS
+	ThroughputPerIOPS int64
+}
+
+// obtained from https://docs.aws.amazon.com/AWSEC2/latest/UserGuide/ebs-volume-types.html
+var volumesLimits = map[string]volumeLimits{
+	"standard": {MinSize: 1, MaxSize: 1024},
+	"gp2":      {MinSize: 1, MaxSize: 16384},
+	"gp3": {
+		MinSize: 1, MaxSize: 16384,
+		MinIOPS: 3000, MaxIOPS: 16000, IOPSPerGB: 500,
+		MinThroughput: 125, MaxThroughput: 1000, ThroughputPerIOPS: 250,
+	},
+	"io1": {MinSize: 4, MaxSize: 16384, MinIOPS: 100, MaxIOPS: 64000, IOPSPerGB: 50, IOPSRequired: true},
+	"io2": {MinSize: 4, MaxSize: 16384, MinIOPS: 100, MaxIOPS: 64000, IOPSPerGB: 500, IOPSRequired: true},
+	"st1": {MinSize: 125, MaxSize: 16384},
+	"sc1": {MinSize: 125, MaxSize: 16384},
+}
+
+// Linux device names recommended for EBS volumes
+var deviceRegexp = regexp.MustCompile(`^/dev/(sd|xvd)[f-p]$`)
+
+// KMS key ID, key ARN, alias name or alias ARN
+var kmsKeyRegexp = regexp.MustCompile(`^(arn:aws[a-z-]*:kms:[a-z0-9-]+:[0-9]{12}:(key|alias)/.+|alias/.+|[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12})$`)
+
+// volumeFields are the field names of the volume options
+type volumeFields struct {
+	Size, Type, IOPS, Throughput, KMSKey string
+}
+
+var rootVolumeFields = volumeFields{
+	Size:       ".storageSize",
+	Type:       ".storageType",
+	IOPS:       ".storageIops",
+	Throughput: ".storageThroughput",
+	KMSKey:     ".storageKmsKey",
+}
+
+var dataVolumeFields = volumeFields{
+	Size:       ".size",
+	Type:       ".type",
+	IOPS:       ".iops",
+	Throughput: ".throughput",
+	KMSKey:     ".kmsKey",
+}
+
+// validateVolume checks the volume options against the volume type limits,
+// the volume is not allowed to be smaller than minSize
+func validateVolume(field string, names volumeFields, volume savedstate.VolumeParams, minSize int64) steps.FieldErrors {
+	var errs steps.FieldErrors
+
+	volumeType := volume.Type
+	if volumeType == "" {
+		volumeType = DefaultVolumeType
+	}
+
+	limits, ok := volumesLimits[volumeType]
+	if !ok || !steps.StrInSlice(volumeType, VolumeTypes) {
+		errs.Add(field+names.Type, steps.CodeUnsupported, "Volume type not handled: %q", volume.Type)
+		return errs
+	}
+
+	if limits.MinSize > minSize {
+		minSize = limits.MinSize
+	}
+	switch {
+	case volume.Size == 0:
+		errs.Add(field+names.Size, steps.CodeRequired, "Volume size not set")
+	case volume.Size < minSize || volume.Size > limits.MaxSize:
+		errs.Add(field+names.Size, steps.CodeOutOfRange, "Volume size should be between %d and %d for %s volumes", minSize, limits.MaxSize, volumeType)
+	}
+
+	switch {
+	case volume.IOPS == 0 && limits.IOPSRequired:
+		errs.Add(field+names.IOPS, steps.CodeRequired, "IOPS should be provisioned for %s volumes", volumeType)
+	case volume.IOPS == 0:
+	case limits.MaxIOPS == 0:
+		errs.Add(field+names.IOPS, steps.CodeUnsupported, "IOPS can not be provisioned for %s volumes", volumeType)
+	case volume.IOPS < limits.MinIOPS || volume.IOPS > limits.MaxIOPS:
+		errs.Add(field+names.IOPS, steps.CodeOutOfRange, "IOPS should be between %d and %d for %s volumes", limits.MinIOPS, limits.MaxIOPS, volumeType)
+	case volume.Size > 0 && volume.IOPS > volume.Size*limits.IOPSPerGB:
+		errs.Add(field+names.IOPS, steps.CodeOutOfRange, "IOPS should not exceed %d per GB for %s volumes", limits.IOPSPerGB, volumeType)
+	}
+
+	iops := volume.IOPS
+	if iops == 0 {
+		iops = limits.MinIOPS
+	}
+	switch {
+	case volume.Throughput == 0:
+	case limits.MaxThroughput == 0:
+		errs.Add(field+names.Throughput, steps.CodeUnsupported, "Throughput can not be provisioned for %s volumes", volumeType)
+	case volume.Throughput < limits.MinThroughput || volume.Throughput > limits.MaxThroughput:
+		errs.Add(field+names.Throughput, steps.CodeOutOfRange, "Throughput should be between %d and %d MiB/s for %s volumes", limits.MinThroughput, limits.MaxThroughput, volumeType)
+	case volume.Throughput*1000 > iops*limits.ThroughputPerIOPS:
+		errs.Add(field+names.Throughput, steps.CodeOutOfRange, "Throughput should not exceed %d MiB/s for %d IOPS", iops*limits.ThroughputPerIOPS/1000, iops)
+	}
+
+	switch {
+	case volume.KMSKey == "":
+	case !volume.Encrypted:
+		errs.Add(field+names.KMSKey, steps.CodeConflict, "KMS key is set for the volume not encrypted")
+	case !kmsKeyRegexp.MatchString(volume.KMSKey):
+		errs.Add(field+names.KMSKey, steps.CodeInvalid, "KMS key should be a key ID, alias or ARN: %q", volume.KMSKey)
+	}
+
+	return errs
+}
+
+// validateVolumes checks the root and the data volumes of the group
+func validateVolumes(field string, group savedstate.NodesParams) steps.FieldErrors {
+	errs := validateVolume(field, rootVolumeFields, group.RootVolume(), MinVolumeSize)
+
+	seen := make(map[string]bool, len(group.Volumes))
+	for i, volume := range group.Volumes {
+		volumeField := fmt.Sprintf("%s.volumes.%d", field, i)
+
+		switch {
+		case volume.Device == "":
+			errs.Add(volumeField+".device", steps.CodeRequired, "Device name not set")
+		case !deviceRegexp.MatchString(volume.Device):
+			errs.Add(volumeField+".device", steps.CodeInvalid, "Device name should be /dev/sd[f-p] or /dev/xvd[f-p]: %q", volume.Device)
+		case seen[volume.Device]:
+			errs.Add(volumeField+".device", steps.CodeConflict, "Device listed twice: %q", volume.Device)
+		}
+		seen[volume.Device] = true
+
+		if volume.Path != "" && (volume.Path[0] != '/' || volume.Path == "/") {
+			errs.Add(volumeField+".path", steps.CodeInvalid, "Mount path should be an absolute path other than root: %q", volume.Path)
+		}
+
+		errs = append(errs, validateVolume(volumeField, dataVolumeFields, volume, 1)...)
+	}
+
+	return errs
+}
+
+func toVolumeParams(req []*models.DataVolume) []savedstate.VolumeParams {
+	if len(req) == 0 {
+		return nil
+	}
+
+	res := make([]savedstate.VolumeParams, 0, len(req))
+	for _, volume := range req {
+		res = append(
+			res,
+			savedstate.VolumeParams{
+				Device:     volume.Device,
+				Size:       volume.Size,
+				Type:       volume.Type,
+				IOPS:       volume.Iops,
+				Throughput: volume.Throughput,
+				Encrypted:  volume.Encrypted,
+				KMSKey:     volume.KmsKey,
+				Path:       volume.Path,
+			},
+		)
+	}
+
+	return res
+}
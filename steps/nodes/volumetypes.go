@@ -8,7 +8,9 @@ import srcPkg "github.com/aws/aws-sdk-go/service/ec2"
 var VolumeTypes = []string{
 	srcPkg.VolumeTypeStandard,
 	srcPkg.VolumeTypeIo1,
+	srcPkg.VolumeTypeIo2,
 	srcPkg.VolumeTypeGp2,
 	srcPkg.VolumeTypeSc1,
 	srcPkg.VolumeTypeSt1,
+	srcPkg.VolumeTypeGp3,
 }
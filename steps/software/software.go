@@ -8,8 +8,12 @@ import (
 	"git.arilot.com/kuberstack/kuberstack-installer/predefined"
 	"git.arilot.com/kuberstack/kuberstack-installer/protocol/gen/models"
 	"git.arilot.com/kuberstack/kuberstack-installer/savedstate"
+	"git.arilot.com/kuberstack/kuberstack-installer/steps"
 )
 
+// ClusterAutoscalerID is the ID of the cluster autoscaler product, it is deployed by the installer
+const ClusterAutoscalerID = "6"
+
 // GetProducts returns a copy of predefined products array
 func GetProducts(search string, tags []string) []*models.GetSoftwareProductsOKBodyItems {
 	res := make([]*models.GetSoftwareProductsOKBodyItems, 0, len(predefined.Products))
@@ -44,11 +48,44 @@ func Save(
 	products []string,
 	principal savedstate.Principal,
 ) error {
+	if errs := ValidateClusterType(principal.Sess.Type, products); len(errs) > 0 {
+		return errs
+	}
+
 	principal.Sess.Products = products
 
 	return conn.SaveState(principal.ID, principal.Sess)
 }
 
+// ValidateClusterType checks all the products bundled with the cluster type chosen are selected
+func ValidateClusterType(typeID int64, products []string) steps.FieldErrors {
+	clusterType := predefined.GetType(typeID)
+	if clusterType == nil {
+		return nil
+	}
+
+	var errs steps.FieldErrors
+
+	for _, id := range clusterType.Software {
+		if !steps.StrInSlice(strconv.Itoa(id), products) {
+			errs.Add(
+				"products",
+				steps.CodeRequired,
+				"%s is bundled with %s cluster and can not be removed",
+				predefined.GetProductNameByID(strconv.Itoa(id)),
+				clusterType.Name,
+			)
+		}
+	}
+
+	return errs
+}
+
+// Selected checks if the product is in the list of products selected
+func Selected(products []string, id string) bool {
+	return steps.StrInSlice(id, products)
+}
+
 func strSlicesCrossed(s1 []string, s2 []string) bool {
 	for _, v1 := range s1 {
 		for _, v2 := range s2 {
@@ -0,0 +1,11 @@
+package steps
+
+// StrInSlice checks the string is one of the slice items
+func StrInSlice(str string, slc []string) bool {
+	for _, chk := range slc {
+		if str == chk {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package validation
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"git.arilot.com/kuberstack/kuberstack-installer/steps"
+)
+
+// AWS limits the number of tags per resource to 50,
+// the rest are left for the tags set by kops and the installer itself
+const (
+	maxTags           = 30
+	maxTagKeyLength   = 127
+	maxTagValueLength = 255
+)
+
+// prefixes of the tags set by AWS, kops and the installer
+var reservedTagPrefixes = []string{
+	"aws:",
+	"kubernetes.io/",
+	"k8s.io/",
+	"kops.k8s.io/",
+	"kuberstack.com/",
+}
+
+// tags set by kops and the installer
+var reservedTags = []string{
+	"KubernetesCluster",
+	"Name",
+}
+
+// ValidateTags checks the cost-allocation tags are accepted by AWS
+// and can be passed to kops as cloud labels
+func ValidateTags(tags map[string]string) steps.FieldErrors {
+	var errs steps.FieldErrors
+
+	if len(tags) > maxTags {
+		errs.Add("tags", steps.CodeOutOfRange, "No more than %d tags allowed, got %d", maxTags, len(tags))
+	}
+
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := tags[key]
+		field := "tags." + key
+
+		switch {
+		case key == "":
+			errs.Add("tags", steps.CodeInvalid, "Tag key should not be empty")
+		case utf8.RuneCountInString(key) > maxTagKeyLength:
+			errs.Add(field, steps.CodeOutOfRange, "Tag key should not be longer than %d characters", maxTagKeyLength)
+		case strings.ContainsAny(key, ",="):
+			errs.Add(field, steps.CodeInvalid, "Tag key should not contain commas and equal signs")
+		case isReservedTag(key):
+			errs.Add(field, steps.CodeConflict, "Tag key is reserved: %q", key)
+		}
+
+		switch {
+		case utf8.RuneCountInString(value) > maxTagValueLength:
+			errs.Add(field, steps.CodeOutOfRange, "Tag value should not be longer than %d characters", maxTagValueLength)
+		case strings.Contains(value, ","):
+			errs.Add(field, steps.CodeInvalid, "Tag value should not contain commas")
+		}
+	}
+
+	return errs
+}
+
+func isReservedTag(key string) bool {
+	for _, reserved := range reservedTags {
+		if key == reserved {
+			return true
+		}
+	}
+	for _, prefix := range reservedTagPrefixes {
+		if strings.HasPrefix(strings.ToLower(key), prefix) {
+			return true
+		}
+	}
+	return false
+}
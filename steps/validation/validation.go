@@ -0,0 +1,112 @@
+// Package validation is the single place the whole cluster spec is checked at
+package validation
+
+import (
+	"regexp"
+	"strings"
+
+	"git.arilot.com/kuberstack/kuberstack-installer/savedstate"
+	"git.arilot.com/kuberstack/kuberstack-installer/steps"
+	"git.arilot.com/kuberstack/kuberstack-installer/steps/network"
+	"git.arilot.com/kuberstack/kuberstack-installer/steps/nodes"
+	"git.arilot.com/kuberstack/kuberstack-installer/steps/software"
+	"git.arilot.com/kuberstack/kuberstack-installer/steps/versions"
+)
+
+const (
+	maxLabelLength  = 63
+	maxDomainLength = 253
+)
+
+var labelRegexp = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+
+// Validate checks the whole cluster spec saved and returns all the problems found
+func Validate(sess *savedstate.State) steps.FieldErrors {
+	errs := ValidateCluster(sess)
+
+	if sess.Region == "" {
+		errs.Add("region", steps.CodeRequired, "Region not set")
+	}
+	if sess.SSHPubKey == "" {
+		errs.Add("ssh_pub_key", steps.CodeRequired, "SSH public key not set")
+	}
+
+	errs = append(errs, ValidateTags(sess.Tags)...)
+	errs = append(errs, nodes.ValidateGroup("master", nodes.RoleMaster, sess.Master, sess.Region)...)
+	errs = append(errs, nodes.ValidateGroup("nodes", nodes.RoleNode, sess.Nodes, sess.Region)...)
+	errs = append(errs, nodes.ValidateGroups(sess.Groups, sess.Region)...)
+	errs = append(errs, nodes.ValidateClusterType(sess.Type, sess.Master, sess.Nodes)...)
+	errs = append(errs, software.ValidateClusterType(sess.Type, sess.Products)...)
+	errs = append(errs, network.Validate(sess)...)
+	errs = append(errs, network.ValidateBastion(sess)...)
+	errs = append(errs, network.ValidateNetworking(sess)...)
+	errs = append(errs, network.ValidateAccess(sess)...)
+	errs = append(errs, versions.Validate(sess)...)
+
+	if software.Selected(sess.Products, software.ClusterAutoscalerID) && !isAutoscaled(sess) {
+		errs.Add("products", steps.CodeConflict, "Cluster autoscaler requires a group of nodes with max instances above the min ones")
+	}
+
+	return errs
+}
+
+// ValidateCluster checks the cluster identity only: its name, domain and state store.
+// This is enough to find the cluster created before.
+func ValidateCluster(sess *savedstate.State) steps.FieldErrors {
+	errs := ValidateName(sess.Name, sess.Domain)
+
+	if sess.Bucket == "" {
+		errs.Add("bucket", steps.CodeRequired, "State store bucket not set")
+	}
+
+	return errs
+}
+
+// ValidateName checks the cluster name is a valid DNS label
+// and the cluster domain name built of it is a valid one
+func ValidateName(name string, domain string) steps.FieldErrors {
+	var errs steps.FieldErrors
+
+	name = strings.ToLower(name)
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+
+	switch {
+	case name == "":
+		errs.Add("name", steps.CodeRequired, "Cluster name not set")
+	case len(name) > maxLabelLength:
+		errs.Add("name", steps.CodeOutOfRange, "Cluster name should not be longer than %d characters", maxLabelLength)
+	case !labelRegexp.MatchString(name):
+		errs.Add("name", steps.CodeInvalid, "Cluster name should consist of latin letters, digits and hyphens, starting and ending with a letter or digit")
+	}
+
+	if domain == "" {
+		errs.Add("domain", steps.CodeRequired, "Domain not set")
+		return errs
+	}
+
+	for _, label := range strings.Split(domain, ".") {
+		if len(label) > maxLabelLength || !labelRegexp.MatchString(label) {
+			errs.Add("domain", steps.CodeInvalid, "Domain name is not valid: %q", domain)
+			break
+		}
+	}
+
+	if len(name)+len(".")+len(domain) > maxDomainLength {
+		errs.Add("name", steps.CodeOutOfRange, "Cluster domain name should not be longer than %d characters", maxDomainLength)
+	}
+
+	return errs
+}
+
+// isAutoscaled checks if there is a group of nodes to be autoscaled
+func isAutoscaled(sess *savedstate.State) bool {
+	if sess.Nodes.MaxSize > sess.Nodes.Quantity {
+		return true
+	}
+	for _, group := range sess.Groups {
+		if group.MaxSize > group.Quantity {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+// Package versions handles the Kubernetes version and the kops channel the cluster is created with
+package versions
+
+import (
+	"git.arilot.com/kuberstack/kuberstack-installer/db"
+	"git.arilot.com/kuberstack/kuberstack-installer/predefined"
+	"git.arilot.com/kuberstack/kuberstack-installer/savedstate"
+	"git.arilot.com/kuberstack/kuberstack-installer/steps"
+)
+
+// Save checks the Kubernetes version and the kops channel and saves them to the DB,
+// the defaults are used for the empty ones
+func Save(conn db.Connect, version string, channel string, principal savedstate.Principal) error {
+	state := *principal.Sess
+	state.KubernetesVersion = version
+	state.Channel = channel
+	Resolve(&state)
+
+	errs := Validate(&state)
+	if len(errs) > 0 {
+		return errs
+	}
+
+	principal.Sess.KubernetesVersion = state.KubernetesVersion
+	principal.Sess.Channel = state.Channel
+
+	return conn.SaveState(principal.ID, principal.Sess)
+}
+
+// Resolve sets the default version and channel if not set,
+// the version is pinned in the state so the cluster does not depend on the day it is installed
+func Resolve(sess *savedstate.State) {
+	if sess.KubernetesVersion == "" {
+		sess.KubernetesVersion = predefined.DefaultKubernetesVersion()
+	}
+	if sess.Channel == "" {
+		sess.Channel = predefined.Versions.DefaultChannel
+	}
+}
+
+// Validate checks the Kubernetes version and the kops channel are supported by the embedded kops,
+// the empty ones are the defaults
+func Validate(sess *savedstate.State) steps.FieldErrors {
+	var errs steps.FieldErrors
+
+	if sess.KubernetesVersion != "" && predefined.GetKubernetesVersion(sess.KubernetesVersion) == nil {
+		errs.Add("kubernetesVersion", steps.CodeUnsupported, "Kubernetes version is not supported: %q", sess.KubernetesVersion)
+	}
+
+	if sess.Channel != "" && !isChannel(sess.Channel) {
+		errs.Add("channel", steps.CodeUnsupported, "kops channel is not supported: %q", sess.Channel)
+	}
+
+	return errs
+}
+
+func isChannel(channel string) bool {
+	for _, c := range predefined.Versions.Channels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}